@@ -3,8 +3,11 @@ package dbr
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	//"fmt"
+
+	"github.com/gavin2014/lib/go/dbr/dialect"
 )
 
 // InsertStmt builds `INSERT INTO ...`.
@@ -12,12 +15,20 @@ type CaseUpdateStmt struct {
 	runner
 	EventReceiver
 	Dialect
+	guard        guard
 	Table        string
 	PKey         string
 	RunLen       int
 	Column       []string
 	Value        []CaseUpdateValue
 	ReturnColumn []string
+	QueryLabel   string
+	TableMapper  func(string) string
+
+	// keyIndex maps the keys of the first indexed rows of Value
+	// to their row, for Set.
+	keyIndex map[string]int
+	indexed  int
 }
 type CaseUpdateValue struct {
 	Key string
@@ -34,14 +45,43 @@ func (b *CaseUpdateStmt) Build(d Dialect, buf Buffer) error {
 	if len(b.Column) == 0 {
 		return ErrColumnNotSpecified
 	}
+	if len(b.ReturnColumn) > 0 && isDialect(d, dialect.MySQL) {
+		return fmt.Errorf("%w: RETURNING, select the updated rows instead", ErrNotSupported)
+	}
+	for x, v := range b.Value {
+		if x >= b.RunLen && b.RunLen > 0 {
+			break
+		}
+		if len(v.Val) != len(b.Column) {
+			return fmt.Errorf("%w: key %s has %d values for %d columns", ErrValueCount, v.Key, len(v.Val), len(b.Column))
+		}
+	}
 	WhereKey := []string{}
 	buf.WriteString("UPDATE ")
-	buf.WriteString(d.QuoteIdent(b.Table))
+	buf.WriteString(d.QuoteIdent(mapTable(b.TableMapper, b.Table)))
 	buf.WriteString(" SET ")
+	n := 0
 	for i, col := range b.Column {
-		if i > 0 {
+		// a column not set by Set for some keys is left unchanged for them,
+		// and left out if no key of this chunk sets it.
+		set, omitted := 0, false
+		for x, v := range b.Value {
+			if x >= b.RunLen && b.RunLen > 0 {
+				break
+			}
+			if v.Val[i] == OmitColumn {
+				omitted = true
+			} else {
+				set++
+			}
+		}
+		if set == 0 {
+			continue
+		}
+		if n > 0 {
 			buf.WriteString(", ")
 		}
+		n++
 		buf.WriteString(d.QuoteIdent(col))
 		buf.WriteString(" = CASE ")
 		buf.WriteString(d.QuoteIdent(b.PKey))
@@ -49,12 +89,22 @@ func (b *CaseUpdateStmt) Build(d Dialect, buf Buffer) error {
 			if x >= b.RunLen && b.RunLen > 0 {
 				break
 			}
+			if v.Val[i] == OmitColumn {
+				continue
+			}
 			buf.WriteString(" WHEN ? THEN ? ")
 			buf.WriteValue(v.Key)
 			buf.WriteValue(v.Val[i])
 		}
+		if omitted {
+			buf.WriteString(" ELSE ")
+			buf.WriteString(d.QuoteIdent(col))
+		}
 		buf.WriteString(" END ")
 	}
+	if n == 0 {
+		return ErrColumnNotSpecified
+	}
 	for x, v := range b.Value {
 		if x >= b.RunLen && b.RunLen > 0 {
 			break
@@ -67,6 +117,7 @@ func (b *CaseUpdateStmt) Build(d Dialect, buf Buffer) error {
 	} else {
 		b.Value = []CaseUpdateValue{}
 	}
+	b.keyIndex = nil
 
 	buf.WriteString(" WHERE ")
 	buf.WriteString(d.QuoteIdent(b.PKey))
@@ -81,6 +132,16 @@ func (b *CaseUpdateStmt) Build(d Dialect, buf Buffer) error {
 		i++
 	}
 	buf.WriteString(" )")
+
+	if len(b.ReturnColumn) > 0 {
+		buf.WriteString(" RETURNING ")
+		for i, col := range b.ReturnColumn {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			buf.WriteString(d.QuoteIdent(col))
+		}
+	}
 	return nil
 }
 
@@ -98,6 +159,7 @@ func (sess *Session) CaseUpdate(table string) *CaseUpdateStmt {
 	b.runner = sess
 	b.EventReceiver = sess.EventReceiver
 	b.Dialect = sess.Dialect
+	b.TableMapper = sess.tableMapper
 	return b
 }
 
@@ -107,6 +169,7 @@ func (tx *Tx) CaseUpdate(table string) *CaseUpdateStmt {
 	b.runner = tx
 	b.EventReceiver = tx.EventReceiver
 	b.Dialect = tx.Dialect
+	b.TableMapper = tx.tableMapper
 	return b
 }
 
@@ -117,6 +180,8 @@ func (b *CaseUpdateStmt) Columns(column ...string) *CaseUpdateStmt {
 
 // Values adds a tuple to be inserted.
 // The order of the tuple should match Columns.
+// If the number of values does not match, ErrValueCount is returned
+// when the statement is built.
 func (b *CaseUpdateStmt) Values(PKey interface{}, value ...interface{}) *CaseUpdateStmt {
 	pk := fmt.Sprint(PKey)
 	for k, v := range b.Value {
@@ -132,7 +197,74 @@ func (b *CaseUpdateStmt) Values(PKey interface{}, value ...interface{}) *CaseUpd
 	return b
 }
 
-// Returning specifies the returning columns for postgres.
+// Key sets the key column the rows are matched by.
+func (b *CaseUpdateStmt) Key(column string) *CaseUpdateStmt {
+	b.PKey = column
+	return b
+}
+
+// Set updates column to value for the row with key, e.g.
+//
+//	CaseUpdate("users").Key("id").Set(1, "name", "a").Set(2, "name", "b").Set(2, "age", 3)
+//
+// sets name for the keys 1 and 2 with `CASE id WHEN ... END`,
+// and age for the key 2 only with `CASE id WHEN ... ELSE age END`.
+// A column that is not set for a key is left unchanged for it.
+func (b *CaseUpdateStmt) Set(key interface{}, column string, value interface{}) *CaseUpdateStmt {
+	col := 0
+	for col < len(b.Column) && b.Column[col] != column {
+		col++
+	}
+	if col == len(b.Column) {
+		b.Column = append(b.Column, column)
+		for i := range b.Value {
+			b.Value[i].Val = padOmitted(b.Value[i].Val, len(b.Column))
+		}
+	}
+	pk := fmt.Sprint(key)
+	row := b.row(pk)
+	if row < 0 {
+		row = len(b.Value)
+		b.Value = append(b.Value, CaseUpdateValue{Key: pk})
+	}
+	b.Value[row].Val = padOmitted(b.Value[row].Val, len(b.Column))
+	b.Value[row].Val[col] = value
+	return b
+}
+
+// row returns the index of the row with key in Value, or -1 if there is none.
+func (b *CaseUpdateStmt) row(key string) int {
+	if b.keyIndex == nil || b.indexed > len(b.Value) {
+		b.keyIndex, b.indexed = make(map[string]int), 0
+	}
+	for ; b.indexed < len(b.Value); b.indexed++ {
+		if _, ok := b.keyIndex[b.Value[b.indexed].Key]; !ok {
+			b.keyIndex[b.Value[b.indexed].Key] = b.indexed
+		}
+	}
+	i, ok := b.keyIndex[key]
+	if !ok {
+		return -1
+	}
+	if i >= len(b.Value) || b.Value[i].Key != key {
+		// Value was changed since it was indexed
+		b.keyIndex = nil
+		return b.row(key)
+	}
+	return i
+}
+
+// padOmitted pads val with OmitColumn to n values.
+func padOmitted(val []interface{}, n int) []interface{} {
+	for len(val) < n {
+		val = append(val, OmitColumn)
+	}
+	return val
+}
+
+// Returning specifies the columns of the updated rows to be loaded by Load.
+// It is supported by PostgreSQL and SQLite, and Build returns
+// ErrNotSupported on MySQL.
 func (b *CaseUpdateStmt) Returning(column ...string) *CaseUpdateStmt {
 	b.ReturnColumn = column
 	return b
@@ -144,25 +276,59 @@ func (b *CaseUpdateStmt) SetRunLen(i int) *CaseUpdateStmt {
 	b.RunLen = i
 	return b
 }
+
+// Label names the statement. See SelectStmt.Label.
+func (b *CaseUpdateStmt) Label(name string) *CaseUpdateStmt {
+	b.QueryLabel = name
+	return b
+}
+
+// snapshot returns a copy of the statement to be built or executed
+// without consuming its rows.
+func (b *CaseUpdateStmt) snapshot() *CaseUpdateStmt {
+	b.guard.enter("CaseUpdateStmt")
+	defer b.guard.leave()
+	s := *b
+	s.guard = guard{}
+	return &s
+}
+
 func (b *CaseUpdateStmt) Exec() error {
-	var err error
-	for len(b.Value) > 0 && err == nil {
-		_, err = b.ExecContext(context.Background())
-	}
+	_, err := b.ExecContext(context.Background())
 	return err
 }
 
+// ExecContext updates the rows chunk by chunk of RunLen keys,
+// executing every chunk, and returns the result of the last chunk.
+// Without rows nothing is executed, and no rows are affected.
 func (b *CaseUpdateStmt) ExecContext(ctx context.Context) (sql.Result, error) {
-	result, err := exec(ctx, b.runner, b.EventReceiver, b, b.Dialect)
-	if err != nil {
-		return nil, err
+	b.guard.enter("CaseUpdateStmt")
+	defer b.guard.leave()
+	if len(b.Value) == 0 {
+		return driver.RowsAffected(0), nil
+	}
+	for {
+		result, err := exec(ctx, b.runner, b.EventReceiver, b, b.Dialect)
+		if err != nil {
+			return nil, err
+		}
+		if len(b.Value) == 0 {
+			return result, nil
+		}
 	}
-	return result, nil
 }
 
+// LoadContext executes every chunk of the statement and loads
+// the rows it returns into value, like InsertStmt.LoadContext.
 func (b *CaseUpdateStmt) LoadContext(ctx context.Context, value interface{}) error {
-	_, err := query(ctx, b.runner, b.EventReceiver, b, b.Dialect, value)
-	return err
+	b.guard.enter("CaseUpdateStmt")
+	defer b.guard.leave()
+	for len(b.Value) > 0 {
+		if _, err := query(ctx, b.runner, b.EventReceiver, b, b.Dialect, value); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (b *CaseUpdateStmt) Load(value interface{}) error {
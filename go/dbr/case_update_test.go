@@ -0,0 +1,108 @@
+package dbr
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gavin2014/lib/go/dbr/dialect"
+)
+
+func TestCaseUpdateElse(t *testing.T) {
+	sess, db := newTestSession(dialect.MySQL)
+	err := sess.CaseUpdate("users").Key("id").
+		Set(1, "name", "a").
+		Set(2, "name", "b").
+		Set(2, "age", 3).
+		Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkQueries(t, db, "UPDATE `users` SET `name` = CASE `id` WHEN '1' THEN 'a'  WHEN '2' THEN 'b'  END , `age` = CASE `id` WHEN '2' THEN 3  ELSE `age` END  WHERE `id` IN ( '1' ,  '2'  )")
+}
+
+func TestCaseUpdateSetMany(t *testing.T) {
+	b := CaseUpdate("t").Key("id")
+	start := time.Now()
+	for i := 0; i < 20000; i++ {
+		b.Set(i, "a", i)
+		b.Set(i, "b", i)
+	}
+	b.Set(5, "a", -1)
+	if len(b.Value) != 20000 || b.Value[5].Val[0] != -1 {
+		t.Fatalf("%d rows, row 5 = %v", len(b.Value), b.Value[5].Val)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Fatalf("Set took %v", d)
+	}
+
+	// keys are still found after Build consumed a chunk
+	b.SetRunLen(10)
+	if err := b.Build(dialect.MySQL, NewBuffer()); err != nil {
+		t.Fatal(err)
+	}
+	b.Set(15, "a", -2)
+	if len(b.Value) != 19990 || b.Value[5].Key != "15" || b.Value[5].Val[0] != -2 {
+		t.Fatalf("%d rows, row 5 = %+v", len(b.Value), b.Value[5])
+	}
+}
+
+func TestCaseUpdateValueCount(t *testing.T) {
+	for _, value := range [][]interface{}{{1}, {1, 2, 3}} {
+		stmt := CaseUpdate("t").Key("id").Columns("a", "b").Values(1, 1, 2).Values(2, value...)
+		if err := stmt.Build(dialect.MySQL, NewBuffer()); !errors.Is(err, ErrValueCount) {
+			t.Errorf("%d values: Build() = %v, want ErrValueCount", len(value), err)
+		}
+	}
+}
+
+func TestCaseUpdateReturning(t *testing.T) {
+	sess, db := newTestSession(dialect.PostgreSQL)
+	db.addRows([]string{"id", "name"}, []driver.Value{int64(1), "a"}, []driver.Value{int64(2), "b"})
+	db.addRows([]string{"id", "name"}, []driver.Value{int64(3), "c"})
+	type user struct {
+		ID   int64
+		Name string
+	}
+	var users []user
+	err := sess.CaseUpdate("users").Key("id").
+		Set(1, "name", "a").
+		Set(2, "name", "b").
+		Set(3, "name", "c").
+		Returning("id", "name").
+		SetRunLen(2).
+		Load(&users)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []user{{1, "a"}, {2, "b"}, {3, "c"}}; !reflect.DeepEqual(users, want) {
+		t.Fatalf("loaded %v, want %v", users, want)
+	}
+	checkQueries(t, db,
+		`UPDATE "users" SET "name" = CASE "id" WHEN '1' THEN 'a'  WHEN '2' THEN 'b'  END  WHERE "id" IN ( '1' ,  '2'  ) RETURNING "id","name"`,
+		`UPDATE "users" SET "name" = CASE "id" WHEN '3' THEN 'c'  END  WHERE "id" IN ( '3'  ) RETURNING "id","name"`,
+	)
+
+	stmt := CaseUpdate("users").Key("id").Set(1, "name", "a").Returning("id")
+	if err := stmt.Build(dialect.MySQL, NewBuffer()); !errors.Is(err, ErrNotSupported) {
+		t.Fatalf("Build on MySQL = %v, want ErrNotSupported", err)
+	}
+}
+
+func TestCaseUpdateEmpty(t *testing.T) {
+	sess, db := newTestSession(dialect.MySQL)
+	result, err := sess.CaseUpdate("t").Key("id").Columns("a").ExecContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := result.RowsAffected(); n != 0 {
+		t.Fatalf("%d rows affected, want 0", n)
+	}
+	if err := sess.ExecBatch(context.Background(), CaseUpdate("t").Key("id").Columns("a")); err != nil {
+		t.Fatal(err)
+	}
+	checkQueries(t, db, "BEGIN", "COMMIT")
+}
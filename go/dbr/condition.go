@@ -1,7 +1,10 @@
 package dbr
 
 import (
+	"fmt"
 	"reflect"
+
+	"github.com/gavin2014/lib/go/dbr/dialect"
 )
 
 func buildCond(d Dialect, buf Buffer, pred string, cond ...Builder) error {
@@ -74,6 +77,50 @@ func Eq(column string, value interface{}) Builder {
 	})
 }
 
+// InStructs is `IN` with the values of field taken from each struct of slice,
+// e.g. InStructs("id", users, "ID") for the ids of users.
+// Elements can be structs or pointers to them, and nil pointers are skipped.
+// If there are no values, it is translated to false like Eq.
+func InStructs(column string, slice interface{}, field string) Builder {
+	return BuildFunc(func(d Dialect, buf Buffer) error {
+		value, err := structFields(slice, field)
+		if err != nil {
+			return err
+		}
+		return Eq(column, value).Build(d, buf)
+	})
+}
+
+// structFields returns the values of field of each struct in slice.
+func structFields(slice interface{}, field string) ([]interface{}, error) {
+	v := reflect.Indirect(reflect.ValueOf(slice))
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("dbr: %T is not a slice of structs", slice)
+	}
+	value := make([]interface{}, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		elem := v.Index(i)
+		for elem.Kind() == reflect.Ptr || elem.Kind() == reflect.Interface {
+			if elem.IsNil() {
+				break
+			}
+			elem = elem.Elem()
+		}
+		if elem.Kind() == reflect.Ptr || elem.Kind() == reflect.Interface {
+			continue
+		}
+		if elem.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("dbr: %T is not a slice of structs", slice)
+		}
+		f := elem.FieldByName(field)
+		if !f.IsValid() || !f.CanInterface() {
+			return nil, fmt.Errorf("dbr: %s has no exported field %s", elem.Type(), field)
+		}
+		value = append(value, f.Interface())
+	}
+	return value, nil
+}
+
 // Neq is `!=`.
 // When value is nil, it will be translated to `IS NOT NULL`.
 // When value is a slice, it will be translated to `NOT IN`.
@@ -97,6 +144,24 @@ func Neq(column string, value interface{}) Builder {
 	})
 }
 
+// IsDistinctFrom is `IS DISTINCT FROM`, which treats NULL as a comparable value.
+// It is translated to `NOT (column <=> ?)` in MySQL,
+// and `IS NOT` in SQLite.
+func IsDistinctFrom(column string, value interface{}) Builder {
+	return BuildFunc(func(d Dialect, buf Buffer) error {
+		switch {
+		case isDialect(d, dialect.MySQL):
+			buf.WriteString("NOT (")
+			buildCmp(d, buf, "<=>", column, value)
+			buf.WriteString(")")
+			return nil
+		case isDialect(d, dialect.SQLite3):
+			return buildCmp(d, buf, "IS NOT", column, value)
+		}
+		return buildCmp(d, buf, "IS DISTINCT FROM", column, value)
+	})
+}
+
 // Gt is `>`.
 func Gt(column string, value interface{}) Builder {
 	return BuildFunc(func(d Dialect, buf Buffer) error {
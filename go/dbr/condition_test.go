@@ -0,0 +1,64 @@
+package dbr
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gavin2014/lib/go/dbr/dialect"
+)
+
+func checkBuild(t *testing.T, b Builder, d Dialect, query string, value ...interface{}) {
+	t.Helper()
+	buf := NewBuffer()
+	if err := b.Build(d, buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != query {
+		t.Errorf("sql = %s, want %s", buf.String(), query)
+	}
+	if got := buf.Value(); len(got) != 0 || len(value) != 0 {
+		if !reflect.DeepEqual(got, value) {
+			t.Errorf("values = %v, want %v", got, value)
+		}
+	}
+}
+
+func TestIsDistinctFrom(t *testing.T) {
+	for _, test := range []struct {
+		d     Dialect
+		query string
+	}{
+		{dialect.MySQL, "NOT (`a` <=> ?)"},
+		{dialect.PostgreSQL, `"a" IS DISTINCT FROM ?`},
+		{dialect.SQLite3, `"a" IS NOT ?`},
+	} {
+		checkBuild(t, IsDistinctFrom("a", 1), test.d, test.query, 1)
+	}
+	checkBuild(t, And(IsDistinctFrom("a", nil), Eq("b", 2)), dialect.PostgreSQL,
+		`("a" IS DISTINCT FROM ?) AND ("b" = ?)`, nil, 2)
+}
+
+func TestInStructs(t *testing.T) {
+	type entity struct {
+		ID   int
+		Name string
+	}
+	sess, db := newTestSession(dialect.MySQL)
+	entities := []*entity{{ID: 1}, nil, {ID: 3}}
+	var name []string
+	if _, err := sess.Select("name").From("entities").WhereInStructs("id", entities, "ID").Load(&name); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sess.Select("name").From("entities").WhereInStructs("id", []entity{}, "ID").Load(&name); err != nil {
+		t.Fatal(err)
+	}
+	checkQueries(t, db,
+		"SELECT name FROM entities WHERE (`id` IN (1,3))",
+		"SELECT name FROM entities WHERE (0)",
+	)
+
+	err := Select("name").From("entities").WhereInStructs("id", entities, "Missing").Build(dialect.MySQL, NewBuffer())
+	if err == nil {
+		t.Fatal("Build with an unknown field succeeded")
+	}
+}
@@ -0,0 +1,75 @@
+package dbr
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+var constraintPattern = []struct {
+	re *regexp.Regexp
+	// trimTable removes the table prefix of the name, e.g. `users.`.
+	trimTable bool
+}{
+	// PostgreSQL: violates unique constraint "users_email_key"
+	{re: regexp.MustCompile(`constraint "([^"]+)"`)},
+	// MySQL: Duplicate entry 'a@b.c' for key 'users.email'
+	// MySQL 8 prefixes the key with the table name.
+	{re: regexp.MustCompile(`for key '([^']+)'`), trimTable: true},
+	// MySQL: a foreign key constraint fails (..., CONSTRAINT `fk_user` FOREIGN KEY ...)
+	{re: regexp.MustCompile("CONSTRAINT `([^`]+)`")},
+	// MySQL: Check constraint 'chk_age' is violated.
+	{re: regexp.MustCompile(`[Cc]heck constraint '([^']+)'`)},
+	// SQLite: UNIQUE constraint failed: users.email
+	{re: regexp.MustCompile(`constraint failed: (.+)$`)},
+}
+
+// ViolatedConstraint returns the name of the constraint or key that err
+// reports as violated, or "" if err is not a constraint violation.
+//
+// The Constraint or ConstraintName field of PostgreSQL driver errors is used
+// when present. Otherwise the name is parsed from the error message of
+// MySQL (key or constraint name without the table prefix),
+// PostgreSQL (constraint name), or SQLite (the failed columns, e.g. `users.email`).
+func ViolatedConstraint(err error) string {
+	if err == nil {
+		return ""
+	}
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if name := constraintField(e); name != "" {
+			return name
+		}
+	}
+	msg := err.Error()
+	for _, p := range constraintPattern {
+		m := p.re.FindStringSubmatch(msg)
+		if m == nil {
+			continue
+		}
+		name := m[1]
+		if p.trimTable {
+			if dot := strings.LastIndex(name, "."); dot >= 0 {
+				name = name[dot+1:]
+			}
+		}
+		return name
+	}
+	return ""
+}
+
+// constraintField reads the constraint name from driver errors
+// like lib/pq's Error.Constraint, or pgconn's PgError.ConstraintName.
+func constraintField(err error) string {
+	v := reflect.Indirect(reflect.ValueOf(err))
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	for _, name := range []string{"Constraint", "ConstraintName"} {
+		f := v.FieldByName(name)
+		if f.IsValid() && f.Kind() == reflect.String && f.String() != "" {
+			return f.String()
+		}
+	}
+	return ""
+}
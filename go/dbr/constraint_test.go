@@ -0,0 +1,36 @@
+package dbr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type pqError struct {
+	Message    string
+	Constraint string
+}
+
+func (e *pqError) Error() string { return e.Message }
+
+func TestViolatedConstraint(t *testing.T) {
+	for _, test := range []struct {
+		err  error
+		want string
+	}{
+		{&pqError{Message: "pq: duplicate key", Constraint: "users_email_key"}, "users_email_key"},
+		{errors.New(`pq: duplicate key value violates unique constraint "users_email_key"`), "users_email_key"},
+		{errors.New("Error 1062: Duplicate entry 'a@b.c' for key 'users.email'"), "email"},
+		{errors.New("Error 1062: Duplicate entry 'a@b.c' for key 'email'"), "email"},
+		{errors.New("Error 1452: Cannot add or update a child row: a foreign key constraint fails (`db`.`orders`, CONSTRAINT `fk_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`))"), "fk_user"},
+		{errors.New("Error 3819: Check constraint 'chk_age' is violated."), "chk_age"},
+		{errors.New("UNIQUE constraint failed: users.email"), "users.email"},
+		{fmt.Errorf("insert: %w", &pqError{Constraint: "orders_pkey"}), "orders_pkey"},
+		{errors.New("connection refused"), ""},
+		{nil, ""},
+	} {
+		if got := ViolatedConstraint(test.err); got != test.want {
+			t.Errorf("ViolatedConstraint(%v) = %q, want %q", test.err, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package dbr
+
+import (
+	"context"
+)
+
+// CountStmt builds `SELECT COUNT(*) FROM table WHERE ...`.
+type CountStmt struct {
+	runner
+	EventReceiver
+	Dialect
+
+	Table       string
+	WhereCond   []Builder
+	TableMapper func(string) string
+}
+
+// Build writes the statement to buf.
+func (b *CountStmt) Build(d Dialect, buf Buffer) error {
+	buf.WriteString("SELECT COUNT(*)")
+	return buildCountFrom(d, buf, mapTable(b.TableMapper, b.Table), b.WhereCond)
+}
+
+// buildCountFrom writes ` FROM table WHERE ...` of CountStmt and ExistsStmt.
+func buildCountFrom(d Dialect, buf Buffer, table string, where []Builder) error {
+	if table == "" {
+		return ErrTableNotSpecified
+	}
+	buf.WriteString(" FROM ")
+	buf.WriteString(d.QuoteIdent(table))
+	if len(where) > 0 {
+		buf.WriteString(" WHERE ")
+		if err := And(where...).Build(d, buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Count creates a CountStmt to count the rows of table.
+func (sess *Session) Count(table string) *CountStmt {
+	return &CountStmt{
+		runner:        sess,
+		EventReceiver: sess.EventReceiver,
+		Dialect:       sess.Dialect,
+		Table:         table,
+		TableMapper:   sess.tableMapper,
+	}
+}
+
+// Count creates a CountStmt to count the rows of table.
+func (tx *Tx) Count(table string) *CountStmt {
+	return &CountStmt{
+		runner:        tx,
+		EventReceiver: tx.EventReceiver,
+		Dialect:       tx.Dialect,
+		Table:         table,
+		TableMapper:   tx.tableMapper,
+	}
+}
+
+// Where adds a where condition.
+// query can be Builder or string. value is used only if query type is string.
+func (b *CountStmt) Where(query interface{}, value ...interface{}) *CountStmt {
+	b.WhereCond = appendWhere(b.WhereCond, query, value)
+	return b
+}
+
+// Return executes the statement and returns the number of rows.
+func (b *CountStmt) Return() (int64, error) {
+	return b.ReturnContext(context.Background())
+}
+
+// ReturnContext executes the statement with ctx and returns the number of rows.
+func (b *CountStmt) ReturnContext(ctx context.Context) (int64, error) {
+	var n int64
+	_, err := query(ctx, b.runner, b.EventReceiver, b, b.Dialect, &n)
+	return n, err
+}
+
+// ExistsStmt builds `SELECT EXISTS (SELECT 1 FROM table WHERE ...)`.
+type ExistsStmt struct {
+	runner
+	EventReceiver
+	Dialect
+
+	Table       string
+	WhereCond   []Builder
+	TableMapper func(string) string
+}
+
+// Build writes the statement to buf.
+func (b *ExistsStmt) Build(d Dialect, buf Buffer) error {
+	buf.WriteString("SELECT EXISTS (SELECT 1")
+	if err := buildCountFrom(d, buf, mapTable(b.TableMapper, b.Table), b.WhereCond); err != nil {
+		return err
+	}
+	buf.WriteString(")")
+	return nil
+}
+
+// Exists creates an ExistsStmt to check whether table has any matching row.
+func (sess *Session) Exists(table string) *ExistsStmt {
+	return &ExistsStmt{
+		runner:        sess,
+		EventReceiver: sess.EventReceiver,
+		Dialect:       sess.Dialect,
+		Table:         table,
+		TableMapper:   sess.tableMapper,
+	}
+}
+
+// Exists creates an ExistsStmt to check whether table has any matching row.
+func (tx *Tx) Exists(table string) *ExistsStmt {
+	return &ExistsStmt{
+		runner:        tx,
+		EventReceiver: tx.EventReceiver,
+		Dialect:       tx.Dialect,
+		Table:         table,
+		TableMapper:   tx.tableMapper,
+	}
+}
+
+// Where adds a where condition.
+// query can be Builder or string. value is used only if query type is string.
+func (b *ExistsStmt) Where(query interface{}, value ...interface{}) *ExistsStmt {
+	b.WhereCond = appendWhere(b.WhereCond, query, value)
+	return b
+}
+
+// Return executes the statement and returns whether a row matches.
+func (b *ExistsStmt) Return() (bool, error) {
+	return b.ReturnContext(context.Background())
+}
+
+// ReturnContext executes the statement with ctx and returns whether a row matches.
+func (b *ExistsStmt) ReturnContext(ctx context.Context) (bool, error) {
+	var exists bool
+	_, err := query(ctx, b.runner, b.EventReceiver, b, b.Dialect, &exists)
+	return exists, err
+}
+
+// appendWhere adds the condition of Where to where.
+func appendWhere(where []Builder, query interface{}, value []interface{}) []Builder {
+	switch query := query.(type) {
+	case string:
+		return append(where, Expr(query, value...))
+	case Builder:
+		return append(where, query)
+	}
+	return where
+}
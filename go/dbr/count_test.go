@@ -0,0 +1,47 @@
+package dbr
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/gavin2014/lib/go/dbr/dialect"
+)
+
+func TestCount(t *testing.T) {
+	sess, db := newTestSession(dialect.PostgreSQL)
+	db.addRows([]string{"count"}, []driver.Value{int64(3)})
+	n, err := sess.Count("users").Where(Eq("state", "active")).Where("age > ?", 18).Return()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Fatalf("Count() = %d, want 3", n)
+	}
+	checkQueries(t, db, `SELECT COUNT(*) FROM "users" WHERE ("state" = 'active') AND (age > 18)`)
+}
+
+func TestExists(t *testing.T) {
+	sess, db := newTestSession(dialect.MySQL)
+	db.addRows([]string{"exists"}, []driver.Value{true})
+	db.addRows([]string{"exists"}, []driver.Value{false})
+	for _, want := range []bool{true, false} {
+		exists, err := sess.Exists("users").Where(Eq("id", 1)).Return()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exists != want {
+			t.Fatalf("Exists() = %v, want %v", exists, want)
+		}
+	}
+	checkQueries(t, db,
+		"SELECT EXISTS (SELECT 1 FROM `users` WHERE (`id` = 1))",
+		"SELECT EXISTS (SELECT 1 FROM `users` WHERE (`id` = 1))",
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := sess.Exists("users").ReturnContext(ctx); err == nil {
+		t.Fatal("ReturnContext with a canceled context succeeded")
+	}
+}
@@ -0,0 +1,45 @@
+package dbr
+
+// cte is a common table expression of a WITH clause.
+type cte struct {
+	name    string
+	builder Builder
+}
+
+// withClause is the WITH clause of a statement.
+type withClause struct {
+	cte       []cte
+	recursive bool
+}
+
+func (w *withClause) add(name string, builder Builder, recursive bool) {
+	w.cte = append(w.cte, cte{name: name, builder: builder})
+	if recursive {
+		w.recursive = true
+	}
+}
+
+// Build writes `WITH name AS (...), ... ` before the main clause,
+// so the values of the expressions come before its own.
+func (w *withClause) Build(d Dialect, buf Buffer) error {
+	if len(w.cte) == 0 {
+		return nil
+	}
+	buf.WriteString("WITH ")
+	if w.recursive {
+		buf.WriteString("RECURSIVE ")
+	}
+	for i, c := range w.cte {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(d.QuoteIdent(c.name))
+		buf.WriteString(" AS (")
+		if err := c.builder.Build(d, buf); err != nil {
+			return err
+		}
+		buf.WriteString(")")
+	}
+	buf.WriteString(" ")
+	return nil
+}
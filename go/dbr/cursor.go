@@ -0,0 +1,120 @@
+package dbr
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/gavin2014/lib/go/dbr/dialect"
+)
+
+var cursorSeq uint64
+
+// Cursor reads the result of a SelectStmt in batches
+// with a PostgreSQL server-side cursor.
+type Cursor struct {
+	tx        *Tx
+	log       EventReceiver
+	name      string
+	fetchSize int
+	closed    bool
+}
+
+// DeclareCursor declares a PostgreSQL cursor for the statement,
+// so that a huge result can be read in batches of fetchSize rows
+// without buffering it in memory.
+//
+// The statement must be created from a Tx, and the cursor is only valid
+// until the Tx ends. ErrNotSupported is returned for other dialects.
+// Like other selects in a Tx, rows are locked with FOR UPDATE
+// unless Lock(false) is set.
+func (b *SelectStmt) DeclareCursor(ctx context.Context, fetchSize int) (*Cursor, error) {
+	tx, ok := b.runner.(*Tx)
+	if !ok {
+		return nil, ErrCursorNotInTx
+	}
+	if !isDialect(b.Dialect, dialect.PostgreSQL) {
+		return nil, ErrNotSupported
+	}
+	if fetchSize <= 0 {
+		fetchSize = defaultRunLen
+	}
+
+	query, value, err := buildQuery(b, b.Dialect)
+	if err != nil {
+		return nil, b.EventErrKv("dbr.cursor.interpolate", err, eventKvs(ctx, b, kvs{
+			"sql":  query,
+			"args": fmt.Sprint(logArgs(tx, value)),
+		}))
+	}
+
+	c := &Cursor{
+		tx:        tx,
+		log:       b.EventReceiver,
+		name:      "dbr_cursor_" + strconv.FormatUint(atomic.AddUint64(&cursorSeq, 1), 10),
+		fetchSize: fetchSize,
+	}
+	query = "DECLARE " + c.name + " CURSOR FOR " + query
+	_, err = tx.ExecContext(ctx, query, value...)
+	if err != nil {
+		return nil, b.EventErrKv("dbr.cursor.declare", err, eventKvs(ctx, b, kvs{
+			"sql": query,
+		}))
+	}
+	return c, nil
+}
+
+// FetchNext loads the next batch of rows into value like Load,
+// and returns the number of rows loaded.
+// The cursor is closed when a batch is not full, and
+// FetchNext returns 0 once the cursor is exhausted.
+func (c *Cursor) FetchNext(value interface{}) (int, error) {
+	return c.FetchNextContext(context.Background(), value)
+}
+
+// FetchNextContext is like FetchNext, and runs the FETCH with ctx.
+func (c *Cursor) FetchNextContext(ctx context.Context, value interface{}) (int, error) {
+	if c.closed {
+		return 0, nil
+	}
+	query := "FETCH " + strconv.Itoa(c.fetchSize) + " FROM " + c.name
+	rows, err := c.tx.QueryContext(ctx, query)
+	if err != nil {
+		return 0, c.log.EventErrKv("dbr.cursor.fetch", err, eventKvs(ctx, nil, kvs{
+			"sql": query,
+		}))
+	}
+	count, err := Load(rows, value)
+	if err != nil {
+		return 0, c.log.EventErrKv("dbr.cursor.fetch.scan", err, eventKvs(ctx, nil, kvs{
+			"sql": query,
+		}))
+	}
+	if count < c.fetchSize {
+		return count, c.CloseContext(ctx)
+	}
+	return count, nil
+}
+
+// Close closes the cursor before it is exhausted.
+func (c *Cursor) Close() error {
+	return c.CloseContext(context.Background())
+}
+
+// CloseContext is like Close, and runs the CLOSE with ctx.
+// Closing a closed cursor does nothing.
+func (c *Cursor) CloseContext(ctx context.Context) error {
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	query := "CLOSE " + c.name
+	_, err := c.tx.ExecContext(ctx, query)
+	if err != nil {
+		return c.log.EventErrKv("dbr.cursor.close", err, eventKvs(ctx, nil, kvs{
+			"sql": query,
+		}))
+	}
+	return nil
+}
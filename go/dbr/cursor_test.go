@@ -0,0 +1,71 @@
+package dbr
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/gavin2014/lib/go/dbr/dialect"
+)
+
+func TestCursor(t *testing.T) {
+	sess, db := newTestSession(dialect.PostgreSQL)
+	tx, err := sess.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	c, err := tx.Select("id").From("t").Lock(false).DeclareCursor(context.Background(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.addRows([]string{"id"}, []driver.Value{int64(1)}, []driver.Value{int64(2)})
+	db.addRows([]string{"id"}, []driver.Value{int64(3)})
+
+	var ids []int64
+	for {
+		var batch []int64
+		n, err := c.FetchNext(&batch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n == 0 {
+			break
+		}
+		ids = append(ids, batch...)
+	}
+	if len(ids) != 3 || ids[2] != 3 {
+		t.Fatalf("ids = %v", ids)
+	}
+
+	q := db.Queries()
+	if len(q) != 5 {
+		t.Fatalf("queries = %q", q)
+	}
+	name := strings.Fields(q[1])[1]
+	want := []string{
+		"BEGIN",
+		"DECLARE " + name + ` CURSOR FOR SELECT id FROM t`,
+		"FETCH 2 FROM " + name,
+		"FETCH 2 FROM " + name,
+		"CLOSE " + name,
+	}
+	checkQueries(t, db, want...)
+}
+
+func TestCursorNotSupported(t *testing.T) {
+	sess, _ := newTestSession(dialect.MySQL)
+	if _, err := sess.Select("id").From("t").DeclareCursor(context.Background(), 2); err != ErrCursorNotInTx {
+		t.Fatalf("err = %v, want ErrCursorNotInTx", err)
+	}
+	tx, err := sess.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Select("id").From("t").DeclareCursor(context.Background(), 2); err != ErrNotSupported {
+		t.Fatalf("err = %v, want ErrNotSupported", err)
+	}
+}
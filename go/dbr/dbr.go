@@ -4,6 +4,7 @@ package dbr
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"strconv"
 	"time"
@@ -31,10 +32,14 @@ func Open(driver, dsn string, log EventReceiver) (*Connection, error) {
 	default:
 		return nil, ErrNotSupported
 	}
-	return &Connection{DB: conn, EventReceiver: log, Dialect: d}, nil
+	return &Connection{DB: conn, EventReceiver: log, Dialect: d, stmts: newStmtCache(conn)}, nil
 }
 
 const (
+	// placeholder marks a value in the SQL written by Build.
+	// It is never sent to the database: the interpolator replaces it with
+	// the value, or with Dialect.Placeholder when the value is bound,
+	// e.g. `$1` on PostgreSQL.
 	placeholder = "?"
 )
 
@@ -44,6 +49,21 @@ type Connection struct {
 	*sql.DB
 	Dialect
 	EventReceiver
+
+	stmts *stmtCache
+}
+
+// Close closes the statements cached by Session.CacheStatements,
+// and then the database.
+func (conn *Connection) Close() error {
+	var err error
+	if conn.stmts != nil {
+		err = conn.stmts.close()
+	}
+	if e := conn.DB.Close(); e != nil {
+		err = e
+	}
+	return err
 }
 
 // Session represents a business unit of execution.
@@ -59,13 +79,138 @@ type Session struct {
 	*Connection
 	EventReceiver
 	Timeout time.Duration
+
+	partition       PartitionFunc
+	insertBatchSize int
+	dryRun          bool
+	tableMapper     func(string) string
+	resultFallback  int64
+	quietChunks     bool
+	cacheStmts      bool
+	redactArgs      bool
 }
 
+// PartitionFunc returns the table a row should be written to.
+// table is the table given to InsertInto, and row maps each column to its value.
+type PartitionFunc func(table string, row map[string]interface{}) string
+
 // GetTimeout returns current timeout enforced in session.
 func (sess *Session) GetTimeout() time.Duration {
 	return sess.Timeout
 }
 
+// PartitionRouter sets fn to route inserts made in the session to
+// partition tables, e.g. `events` to `events_2024_01`.
+// Rows are grouped by the table fn returns, and each group is inserted
+// with its own batch of statements.
+// Selects are routed with SelectStmt.FromPartition.
+func (sess *Session) PartitionRouter(fn PartitionFunc) {
+	sess.partition = fn
+}
+
+// SetInsertBatchSize sets the default number of rows inserted per statement
+// for every InsertStmt created from the session.
+// SetRunLen still overrides it per statement.
+func (sess *Session) SetInsertBatchSize(n int) {
+	sess.insertBatchSize = n
+}
+
+// SuppressChunkEvents sets whether inserts made in the session leave out
+// the timing event of each chunk, and only send the "dbr.insert.batch"
+// event of the whole insert.
+func (sess *Session) SuppressChunkEvents(suppress bool) {
+	sess.quietChunks = suppress
+}
+
+// SetTableMapper sets fn to rewrite the table names of select, insert,
+// update, and delete statements created from the session at build time,
+// e.g. to route `orders` to the shard `orders_042`.
+// Statements created with the BySql methods are not affected.
+func (sess *Session) SetTableMapper(fn func(name string) string) {
+	sess.tableMapper = fn
+}
+
+// mapTable returns table rewritten by mapper if it is set.
+func mapTable(mapper func(string) string, table string) string {
+	if mapper == nil {
+		return table
+	}
+	return mapper(table)
+}
+
+// DryRun sets whether statements run in the session only log
+// their SQL instead of executing it.
+// In dry-run mode, Exec returns a result with 0 rows affected,
+// Load loads no rows, and Rows returns ErrDryRun.
+func (sess *Session) DryRun(enable bool) {
+	sess.dryRun = enable
+}
+
+// GetDryRun returns whether the session is in dry-run mode.
+func (sess *Session) GetDryRun() bool {
+	return sess.dryRun
+}
+
+// RedactArgs sets whether the args bound to statements run in the session
+// are replaced with "[redacted]" in QueryError.Args and the "args" kv of
+// events, e.g. when they hold passwords or personal data.
+// Values interpolated into the SQL of a statement are not affected;
+// use CacheStatements to bind them instead.
+func (sess *Session) RedactArgs(redact bool) {
+	sess.redactArgs = redact
+}
+
+// GetRedactArgs returns the value set by RedactArgs.
+func (sess *Session) GetRedactArgs() bool {
+	return sess.redactArgs
+}
+
+// SetResultFallback sets the value ExecResult returns when the driver
+// cannot report the rows affected or the last insert id,
+// e.g. LastInsertID on PostgreSQL. It is 0 by default.
+func (sess *Session) SetResultFallback(n int64) {
+	sess.resultFallback = n
+}
+
+// GetResultFallback returns the value set by SetResultFallback.
+func (sess *Session) GetResultFallback() int64 {
+	return sess.resultFallback
+}
+
+// WarmPool opens n connections and pings them, so the pool is filled
+// before the first requests. The connections are returned to the pool
+// as idle connections, so SetMaxIdleConns should allow at least n.
+// ErrPoolTooSmall is returned up front if SetMaxOpenConns is lower than n,
+// and an error if n connections cannot be opened before ctx is done.
+func (sess *Session) WarmPool(ctx context.Context, n int) error {
+	if max := sess.DB.Stats().MaxOpenConnections; max > 0 && n > max {
+		return sess.EventErrKv("dbr.warm_pool", ErrPoolTooSmall, kvs{
+			"max_open": strconv.Itoa(max),
+		})
+	}
+	conns := make([]*sql.Conn, 0, n)
+	defer func() {
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+	for len(conns) < n {
+		conn, err := sess.Conn(ctx)
+		if err != nil {
+			return sess.EventErrKv("dbr.warm_pool", err, kvs{
+				"open": strconv.Itoa(len(conns)),
+			})
+		}
+		conns = append(conns, conn)
+		if err := conn.PingContext(ctx); err != nil {
+			return sess.EventErrKv("dbr.warm_pool", err, kvs{
+				"open": strconv.Itoa(len(conns)),
+			})
+		}
+	}
+	return nil
+}
+
 // NewSession instantiates a Session from Connection.
 // If log is nil, Connection EventReceiver is used.
 func (conn *Connection) NewSession(log EventReceiver) *Session {
@@ -99,6 +244,9 @@ type SessionRunner interface {
 
 type runner interface {
 	GetTimeout() time.Duration
+	GetDryRun() bool
+	GetResultFallback() int64
+	GetRedactArgs() bool
 	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
 }
@@ -111,18 +259,27 @@ func exec(ctx context.Context, runner runner, log EventReceiver, builder Builder
 		defer cancel()
 	}
 
+	cache, tx := runnerCache(runner)
 	i := interpolator{
 		Buffer:       NewBuffer(),
 		Dialect:      d,
 		IgnoreBinary: true,
+		Bind:         cache != nil,
 	}
 	err := i.encodePlaceholder(builder, true)
 	query, value := i.String(), i.Value()
 	if err != nil {
-		return nil, log.EventErrKv("dbr.exec.interpolate", err, kvs{
+		return nil, newQueryError(i.query, logArgs(runner, i.args), log.EventErrKv("dbr.exec.interpolate", err, eventKvs(ctx, builder, kvs{
 			"sql":  query,
-			"args": fmt.Sprint(value),
-		})
+			"args": fmt.Sprint(logArgs(runner, value)),
+		})))
+	}
+
+	query = labelQuery(builder, query)
+
+	if runner.GetDryRun() {
+		log.TimingKv("dbr.exec.dry_run", 0, eventKvs(ctx, builder, queryKvs(query, logArgs(runner, value))))
+		return driver.RowsAffected(0), nil
 	}
 
 	startTime := time.Now()
@@ -132,30 +289,36 @@ func exec(ctx context.Context, runner runner, log EventReceiver, builder Builder
 	//	})
 	//}()
 
-	traceImpl, hasTracingImpl := log.(TracingEventReceiver)
+	traceImpl, hasTracingImpl := tracingReceiver(log)
 	if hasTracingImpl {
 		ctx = traceImpl.SpanStart(ctx, "dbr.exec", query)
 		defer traceImpl.SpanFinish(ctx)
 	}
 
-	result, err := runner.ExecContext(ctx, query, value...)
+	var result sql.Result
+	if cache != nil {
+		result, err = cache.exec(ctx, tx, query, value)
+	} else {
+		result, err = runner.ExecContext(ctx, query, value...)
+	}
 	if err != nil {
 		if hasTracingImpl {
 			traceImpl.SpanError(ctx, err)
 		}
-		return result, log.EventErrKv("dbr.exec.exec", err, kvs{
+		err = log.EventErrKv("dbr.exec.exec", err, eventKvs(ctx, builder, kvs{
 			"sql":  query,
 			"time": strconv.FormatInt(time.Since(startTime).Nanoseconds()/1e6, 10),
-		})
+		}))
+		return result, newQueryError(i.query, logArgs(runner, i.args), err)
 	}
 
-	log.TimingKv("dbr.exec", time.Since(startTime).Nanoseconds(), kvs{
-		"sql": query,
-	})
+	if timingEnabled(log) {
+		log.TimingKv("dbr.exec", time.Since(startTime).Nanoseconds(), eventKvs(ctx, builder, queryKvs(query, logArgs(runner, value))))
+	}
 	return result, nil
 }
 
-func queryRows(ctx context.Context, runner runner, log EventReceiver, builder Builder, d Dialect) (string, *sql.Rows, error) {
+func queryRows(ctx context.Context, runner runner, log EventReceiver, builder Builder, d Dialect) (string, []interface{}, *sql.Rows, error) {
 	// discard the timeout set in the runner, the context should not be canceled
 	// implicitly here but explicitly by the caller since the returned *sql.Rows
 	// may still listening to the context
@@ -167,10 +330,17 @@ func queryRows(ctx context.Context, runner runner, log EventReceiver, builder Bu
 	err := i.encodePlaceholder(builder, true)
 	query, value := i.String(), i.Value()
 	if err != nil {
-		return query, nil, log.EventErrKv("dbr.select.interpolate", err, kvs{
+		return query, value, nil, newQueryError(i.query, logArgs(runner, i.args), log.EventErrKv("dbr.select.interpolate", err, eventKvs(ctx, builder, kvs{
 			"sql":  query,
-			"args": fmt.Sprint(value),
-		})
+			"args": fmt.Sprint(logArgs(runner, value)),
+		})))
+	}
+
+	query = labelQuery(builder, query)
+
+	if runner.GetDryRun() {
+		log.TimingKv("dbr.select.dry_run", 0, eventKvs(ctx, builder, queryKvs(query, logArgs(runner, value))))
+		return query, value, nil, ErrDryRun
 	}
 
 	startTime := time.Now()
@@ -180,7 +350,7 @@ func queryRows(ctx context.Context, runner runner, log EventReceiver, builder Bu
 	//	})
 	//}()
 
-	traceImpl, hasTracingImpl := log.(TracingEventReceiver)
+	traceImpl, hasTracingImpl := tracingReceiver(log)
 	if hasTracingImpl {
 		ctx = traceImpl.SpanStart(ctx, "dbr.select", query)
 		defer traceImpl.SpanFinish(ctx)
@@ -191,13 +361,13 @@ func queryRows(ctx context.Context, runner runner, log EventReceiver, builder Bu
 		if hasTracingImpl {
 			traceImpl.SpanError(ctx, err)
 		}
-		return query, nil, log.EventErrKv("dbr.select.load.query", err, kvs{
+		return query, value, nil, newQueryError(i.query, logArgs(runner, i.args), log.EventErrKv("dbr.select.load.query", err, eventKvs(ctx, builder, kvs{
 			"sql":  query,
 			"time": strconv.FormatInt(time.Since(startTime).Nanoseconds()/1e6, 10),
-		})
+		})))
 	}
 
-	return query, rows, nil
+	return query, value, rows, nil
 }
 
 func query(ctx context.Context, runner runner, log EventReceiver, builder Builder, d Dialect, dest interface{}) (int, error) {
@@ -209,21 +379,24 @@ func query(ctx context.Context, runner runner, log EventReceiver, builder Builde
 	}
 
 	startTime := time.Now()
-	query, rows, err := queryRows(ctx, runner, log, builder, d)
+	query, value, rows, err := queryRows(ctx, runner, log, builder, d)
+	if err == ErrDryRun {
+		return 0, nil
+	}
 	if err != nil {
 		return 0, err
 	}
 	count, err := Load(rows, dest)
 	if err != nil {
-		return 0, log.EventErrKv("dbr.select.load.scan", err, kvs{
+		return 0, log.EventErrKv("dbr.select.load.scan", err, eventKvs(ctx, builder, kvs{
 			"sql":  query,
 			"time": strconv.FormatInt(time.Since(startTime).Nanoseconds()/1e6, 10),
-		})
+		}))
 	}
 
-	log.TimingKv("dbr.select", time.Since(startTime).Nanoseconds(), kvs{
-		"sql": query,
-	})
+	if timingEnabled(log) {
+		log.TimingKv("dbr.select", time.Since(startTime).Nanoseconds(), eventKvs(ctx, builder, queryKvs(query, logArgs(runner, value))))
+	}
 	return count, nil
 }
 
@@ -235,17 +408,25 @@ func count(ctx context.Context, runner runner, log EventReceiver, builder Builde
 	}
 	err := i.encodePlaceholder(builder, true)
 	query, value := i.String(), i.Value()
-	query = fmt.Sprintf("SELECT COUNT(*) FROM (%s) AS count", query)
+	query = labelQuery(builder, fmt.Sprintf("SELECT COUNT(*) FROM (%s) AS count", query))
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM (%s) AS count", i.query)
 	if err != nil {
-		return 0, log.EventErrKv("dbr.select.interpolate", err, kvs{
+		return 0, newQueryError(countQuery, logArgs(runner, i.args), log.EventErrKv("dbr.select.interpolate", err, eventKvs(ctx, builder, kvs{
 			"sql":  query,
-			"args": fmt.Sprint(value),
-		})
+			"args": fmt.Sprint(logArgs(runner, value)),
+		})))
+	}
+
+	if runner.GetDryRun() {
+		log.TimingKv("dbr.count.dry_run", 0, eventKvs(ctx, builder, kvs{
+			"sql": query,
+		}))
+		return 0, nil
 	}
 
 	startTime := time.Now()
 
-	traceImpl, hasTracingImpl := log.(TracingEventReceiver)
+	traceImpl, hasTracingImpl := tracingReceiver(log)
 	if hasTracingImpl {
 		ctx = traceImpl.SpanStart(ctx, "dbr.select", query)
 		defer traceImpl.SpanFinish(ctx)
@@ -256,10 +437,10 @@ func count(ctx context.Context, runner runner, log EventReceiver, builder Builde
 		if hasTracingImpl {
 			traceImpl.SpanError(ctx, err)
 		}
-		return 0, log.EventErrKv("dbr.select.load.query", err, kvs{
+		return 0, newQueryError(countQuery, logArgs(runner, i.args), log.EventErrKv("dbr.select.load.query", err, eventKvs(ctx, builder, kvs{
 			"sql":  query,
 			"time": strconv.FormatInt(time.Since(startTime).Nanoseconds()/1e6, 10),
-		})
+		})))
 	}
 	defer rows.Close()
 	var count int
@@ -267,19 +448,43 @@ func count(ctx context.Context, runner runner, log EventReceiver, builder Builde
 		rows.Scan(&count)
 	}
 
-	log.TimingKv("dbr.count", time.Since(startTime).Nanoseconds(), kvs{
-		"sql": query,
-	})
+	if timingEnabled(log) {
+		log.TimingKv("dbr.count", time.Since(startTime).Nanoseconds(), eventKvs(ctx, builder, kvs{
+			"sql": query,
+		}))
+	}
 	return count, nil
 }
 
 //获取SQL
 func getSQL(builder Builder, d Dialect) (string, error) {
+	query, _, err := buildQuery(builder, d)
+	return query, err
+}
+
+// prepareQuery builds builder with its values bound to the placeholders
+// of d, as exec does with CacheStatements.
+func prepareQuery(builder Builder, d Dialect) (string, []interface{}, error) {
+	i := interpolator{
+		Buffer:       NewBuffer(),
+		Dialect:      d,
+		IgnoreBinary: true,
+		Bind:         true,
+	}
+	if err := i.encodePlaceholder(builder, true); err != nil {
+		return "", nil, err
+	}
+	return labelQuery(builder, i.String()), i.Value(), nil
+}
+
+// buildQuery interpolates builder into the query sent to the database,
+// and the values that are still bound to placeholders.
+func buildQuery(builder Builder, d Dialect) (string, []interface{}, error) {
 	i := interpolator{
 		Buffer:       NewBuffer(),
 		Dialect:      d,
 		IgnoreBinary: true,
 	}
 	err := i.encodePlaceholder(builder, true)
-	return i.String(), err
+	return i.String(), i.Value(), err
 }
@@ -0,0 +1,273 @@
+package dbr
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/gavin2014/lib/go/dbr/dialect"
+)
+
+// fakeDB is a database/sql driver that records the statements it runs,
+// so tests can check the generated SQL without a database.
+type fakeDB struct {
+	mu       sync.Mutex
+	queries  []string
+	args     [][]driver.Value
+	rows     []*fakeRows // returned by queries in order
+	affected []int64     // rows affected by execs in order, 1 when empty
+	lastID   int64
+	execErr  map[string]error
+	onExec   func(query string)
+	prepared int
+	closed   int
+}
+
+func (db *fakeDB) Connect(context.Context) (driver.Conn, error) { return &fakeConn{db}, nil }
+func (db *fakeDB) Driver() driver.Driver                        { return fakeDriver{db} }
+
+type fakeDriver struct{ db *fakeDB }
+
+func (d fakeDriver) Open(string) (driver.Conn, error) { return &fakeConn{d.db}, nil }
+
+// addRows queues rows to be returned by the next query.
+func (db *fakeDB) addRows(column []string, value ...[]driver.Value) {
+	db.mu.Lock()
+	db.rows = append(db.rows, &fakeRows{column: column, value: value})
+	db.mu.Unlock()
+}
+
+// failOn makes the execution of query fail with err.
+func (db *fakeDB) failOn(query string, err error) {
+	db.mu.Lock()
+	if db.execErr == nil {
+		db.execErr = make(map[string]error)
+	}
+	db.execErr[query] = err
+	db.mu.Unlock()
+}
+
+// Queries returns the statements run so far.
+func (db *fakeDB) Queries() []string {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return append([]string(nil), db.queries...)
+}
+
+func (db *fakeDB) record(query string, args []driver.Value) {
+	db.mu.Lock()
+	db.queries = append(db.queries, query)
+	db.args = append(db.args, args)
+	db.mu.Unlock()
+}
+
+func (db *fakeDB) exec(query string, args []driver.Value) (driver.Result, error) {
+	db.record(query, args)
+	if db.onExec != nil {
+		db.onExec(query)
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if err := db.execErr[query]; err != nil {
+		return nil, err
+	}
+	n := int64(1)
+	if len(db.affected) > 0 {
+		n, db.affected = db.affected[0], db.affected[1:]
+	}
+	return fakeResult{id: db.lastID, n: n}, nil
+}
+
+func (db *fakeDB) query(query string, args []driver.Value) (driver.Rows, error) {
+	db.record(query, args)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if err := db.execErr[query]; err != nil {
+		return nil, err
+	}
+	if len(db.rows) == 0 {
+		return &fakeRows{}, nil
+	}
+	rows := db.rows[0]
+	db.rows = db.rows[1:]
+	return rows, nil
+}
+
+type fakeConn struct{ db *fakeDB }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	c.db.mu.Lock()
+	c.db.prepared++
+	c.db.mu.Unlock()
+	return &fakeStmt{c.db, query}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.db.record("BEGIN", nil)
+	return fakeTx{c.db}, nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.db.exec(query, namedValues(args))
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.db.query(query, namedValues(args))
+}
+
+func namedValues(args []driver.NamedValue) []driver.Value {
+	var value []driver.Value
+	for _, arg := range args {
+		value = append(value, arg.Value)
+	}
+	return value
+}
+
+type fakeTx struct{ db *fakeDB }
+
+func (tx fakeTx) Commit() error {
+	tx.db.record("COMMIT", nil)
+	return nil
+}
+
+func (tx fakeTx) Rollback() error {
+	tx.db.record("ROLLBACK", nil)
+	return nil
+}
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s *fakeStmt) Close() error {
+	s.db.mu.Lock()
+	s.db.closed++
+	s.db.mu.Unlock()
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.db.exec(s.query, args)
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.db.query(s.query, args)
+}
+
+type fakeResult struct{ id, n int64 }
+
+func (r fakeResult) LastInsertId() (int64, error) { return r.id, nil }
+func (r fakeResult) RowsAffected() (int64, error) { return r.n, nil }
+
+type fakeRows struct {
+	column []string
+	value  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.column }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.value) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.value[0])
+	r.value = r.value[1:]
+	return nil
+}
+
+// newTestSession returns a session using d on a fakeDB.
+func newTestSession(d Dialect) (*Session, *fakeDB) {
+	db := &fakeDB{}
+	conn := sql.OpenDB(db)
+	c := &Connection{DB: conn, EventReceiver: nullReceiver, Dialect: d, stmts: newStmtCache(conn)}
+	return c.NewSession(nil), db
+}
+
+var testDialects = map[string]Dialect{
+	"mysql":    dialect.MySQL,
+	"postgres": dialect.PostgreSQL,
+	"sqlite3":  dialect.SQLite3,
+}
+
+func checkQueries(t *testing.T, db *fakeDB, want ...string) {
+	t.Helper()
+	if got := db.Queries(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("queries = %q, want %q", got, want)
+	}
+}
+
+func TestWarmPool(t *testing.T) {
+	sess, _ := newTestSession(dialect.MySQL)
+	sess.DB.SetMaxIdleConns(5)
+	if err := sess.WarmPool(context.Background(), 5); err != nil {
+		t.Fatal(err)
+	}
+	if n := sess.DB.Stats().OpenConnections; n != 5 {
+		t.Fatalf("%d open connections, want 5", n)
+	}
+
+	sess.DB.SetMaxOpenConns(3)
+	if err := sess.WarmPool(context.Background(), 4); err != ErrPoolTooSmall {
+		t.Fatalf("WarmPool(4) with 3 max open = %v, want ErrPoolTooSmall", err)
+	}
+}
+
+func TestTableMapper(t *testing.T) {
+	sess, db := newTestSession(dialect.MySQL)
+	sess.SetTableMapper(func(name string) string { return name + "_042" })
+
+	var ids []int64
+	if _, err := sess.Select("id").From("orders").Load(&ids); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sess.InsertInto("orders").Columns("id").Values(1).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sess.Update("orders").Set("n", 1).Where("id = ?", 1).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sess.DeleteFrom("orders").Where("id = ?", 1).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if err := sess.CaseUpdate("orders").Key("id").Set(1, "n", 2).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sess.SelectBySql("SELECT id FROM orders").Load(&ids); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := sess.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Select("id").From("orders").Load(&ids); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.InsertInto("orders").Columns("id").Values(1).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	checkQueries(t, db,
+		"SELECT id FROM orders_042",
+		"INSERT INTO `orders_042` (`id`) VALUES (1)",
+		"UPDATE `orders_042` SET `n` = 1 WHERE (id = 1)",
+		"DELETE FROM `orders_042` WHERE (id = 1)",
+		"UPDATE `orders_042` SET `n` = CASE `id` WHEN '1' THEN 2  END  WHERE `id` IN ( '1'  )",
+		"SELECT id FROM orders",
+		"BEGIN",
+		"SELECT id FROM orders_042 FOR UPDATE ",
+		"INSERT INTO `orders_042` (`id`) VALUES (1)",
+		"COMMIT",
+	)
+}
@@ -3,7 +3,6 @@ package dbr
 import (
 	"context"
 	"database/sql"
-	"strconv"
 )
 
 // DeleteStmt builds `DELETE ...`.
@@ -14,9 +13,13 @@ type DeleteStmt struct {
 
 	raw
 
-	Table      string
-	WhereCond  []Builder
-	LimitCount int64
+	Table       string
+	WhereCond   []Builder
+	LimitCount  int64
+	QueryLabel  string
+	TableMapper func(string) string
+
+	with withClause
 }
 
 type DeleteBuilder = DeleteStmt
@@ -30,8 +33,12 @@ func (b *DeleteStmt) Build(d Dialect, buf Buffer) error {
 		return ErrTableNotSpecified
 	}
 
+	if err := b.with.Build(d, buf); err != nil {
+		return err
+	}
+
 	buf.WriteString("DELETE FROM ")
-	buf.WriteString(d.QuoteIdent(b.Table))
+	buf.WriteString(d.QuoteIdent(mapTable(b.TableMapper, b.Table)))
 
 	if len(b.WhereCond) > 0 {
 		buf.WriteString(" WHERE ")
@@ -40,11 +47,7 @@ func (b *DeleteStmt) Build(d Dialect, buf Buffer) error {
 			return err
 		}
 	}
-	if b.LimitCount >= 0 {
-		buf.WriteString(" LIMIT ")
-		buf.WriteString(strconv.FormatInt(b.LimitCount, 10))
-	}
-	return nil
+	return buildModifyLimit(d, buf, b.LimitCount)
 }
 
 // DeleteFrom creates a DeleteStmt.
@@ -61,6 +64,7 @@ func (sess *Session) DeleteFrom(table string) *DeleteStmt {
 	b.runner = sess
 	b.EventReceiver = sess.EventReceiver
 	b.Dialect = sess.Dialect
+	b.TableMapper = sess.tableMapper
 	return b
 }
 
@@ -70,6 +74,7 @@ func (tx *Tx) DeleteFrom(table string) *DeleteStmt {
 	b.runner = tx
 	b.EventReceiver = tx.EventReceiver
 	b.Dialect = tx.Dialect
+	b.TableMapper = tx.tableMapper
 	return b
 }
 
@@ -102,6 +107,20 @@ func (tx *Tx) DeleteBySql(query string, value ...interface{}) *DeleteStmt {
 	return b
 }
 
+// With adds a common table expression, e.g. a SELECT or a data-modifying
+// statement with RETURNING, to be referred to by name in the statement:
+// `WITH name AS (...) DELETE ...`.
+func (b *DeleteStmt) With(name string, builder Builder) *DeleteStmt {
+	b.with.add(name, builder, false)
+	return b
+}
+
+// WithRecursive is like With, but writes `WITH RECURSIVE`.
+func (b *DeleteStmt) WithRecursive(name string, builder Builder) *DeleteStmt {
+	b.with.add(name, builder, true)
+	return b
+}
+
 // Where adds a where condition.
 // query can be Builder or string. value is used only if query type is string.
 func (b *DeleteStmt) Where(query interface{}, value ...interface{}) *DeleteStmt {
@@ -114,16 +133,33 @@ func (b *DeleteStmt) Where(query interface{}, value ...interface{}) *DeleteStmt
 	return b
 }
 
+// WhereInStructs adds `column IN (...)` with the values of field
+// of each struct in slice. See InStructs.
+func (b *DeleteStmt) WhereInStructs(column string, slice interface{}, field string) *DeleteStmt {
+	return b.Where(InStructs(column, slice, field))
+}
+
+// Limit deletes at most n rows with `LIMIT n`, which only MySQL supports.
+// Build returns ErrNotSupported for other dialects, SQLite included, e.g. on postgres
+// limit the rows with a subquery like `WHERE id IN (SELECT id ... LIMIT n)`.
 func (b *DeleteStmt) Limit(n uint64) *DeleteStmt {
 	b.LimitCount = int64(n)
 	return b
 }
 
+// Label names the statement. See SelectStmt.Label.
+func (b *DeleteStmt) Label(name string) *DeleteStmt {
+	b.QueryLabel = name
+	return b
+}
+
 //获取SQL
-func (b *DeleteStmt) GetSQL() (string, error) {
+// An optional dialect like NoQuote(nil) overrides the dialect of the statement.
+// A statement created without a session is written with MySQL by default.
+func (b *DeleteStmt) GetSQL(d ...Dialect) (string, error) {
 	b1 := *b
 	b2 := &b1
-	return getSQL(b2, b2.Dialect)
+	return getSQL(b2, previewDialect(b2.Dialect, d))
 }
 
 func (b *DeleteStmt) Exec() (sql.Result, error) {
@@ -133,3 +169,13 @@ func (b *DeleteStmt) Exec() (sql.Result, error) {
 func (b *DeleteStmt) ExecContext(ctx context.Context) (sql.Result, error) {
 	return exec(ctx, b.runner, b.EventReceiver, b, b.Dialect)
 }
+
+// ExecR executes the statement like ExecContext,
+// and wraps its result in an ExecResult.
+func (b *DeleteStmt) ExecR(ctx context.Context) (*ExecResult, error) {
+	result, err := b.ExecContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newExecResult(b.runner, result), nil
+}
@@ -0,0 +1,47 @@
+package dbr
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gavin2014/lib/go/dbr/dialect"
+)
+
+func TestDeleteWith(t *testing.T) {
+	moved := InsertBySql("INSERT INTO archive SELECT * FROM orders WHERE created < ? RETURNING id", "2024-01-01")
+	stmt := DeleteFrom("orders").
+		With("moved", moved).
+		Where("id IN (SELECT id FROM moved) AND shop = ?", 5)
+	checkBuild(t, stmt, dialect.PostgreSQL,
+		`WITH "moved" AS (INSERT INTO archive SELECT * FROM orders WHERE created < ? RETURNING id) DELETE FROM "orders" WHERE (id IN (SELECT id FROM moved) AND shop = ?)`,
+		"2024-01-01", 5)
+}
+
+func TestUpdateWithRecursive(t *testing.T) {
+	tree := Expr("SELECT id FROM nodes WHERE id = ? UNION ALL SELECT n.id FROM nodes n JOIN tree ON n.parent = tree.id", 1)
+	stmt := Update("nodes").
+		WithRecursive("tree", tree).
+		Set("hidden", true).
+		Where("id IN (SELECT id FROM tree)")
+	checkBuild(t, stmt, dialect.PostgreSQL,
+		`WITH RECURSIVE "tree" AS (SELECT id FROM nodes WHERE id = ? UNION ALL SELECT n.id FROM nodes n JOIN tree ON n.parent = tree.id) UPDATE "nodes" SET "hidden" = ? WHERE (id IN (SELECT id FROM tree))`,
+		1, true)
+}
+
+func TestModifyLimit(t *testing.T) {
+	checkBuild(t, Update("t").Set("a", 1).Where("b = ?", 2).Limit(10), dialect.MySQL,
+		"UPDATE `t` SET `a` = ? WHERE (b = ?) LIMIT 10", 1, 2)
+	checkBuild(t, DeleteFrom("t").Where("b = ?", 2).Limit(10), dialect.MySQL,
+		"DELETE FROM `t` WHERE (b = ?) LIMIT 10", 2)
+
+	for _, d := range []Dialect{dialect.PostgreSQL, dialect.SQLite3} {
+		for _, stmt := range []Builder{
+			Update("t").Set("a", 1).Where("b = ?", 2).Limit(10),
+			DeleteFrom("t").Where("b = ?", 2).Limit(10),
+		} {
+			if err := stmt.Build(d, NewBuffer()); !errors.Is(err, ErrNotSupported) {
+				t.Errorf("Build(%T) = %v, want ErrNotSupported", d, err)
+			}
+		}
+	}
+}
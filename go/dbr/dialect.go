@@ -1,10 +1,19 @@
 package dbr
 
-import "time"
+import (
+	"time"
+
+	"github.com/gavin2014/lib/go/dbr/dialect"
+)
 
 // Dialect abstracts database driver differences in encoding
 // types, and placeholders.
 type Dialect interface {
+	// QuoteIdent quotes id as an identifier, each segment of a dotted name
+	// like `db.users` on its own, and leaves `*` as is.
+	// Anything else, also a function call like `COUNT(*)`, is quoted
+	// as one name, so a name from user input cannot inject SQL.
+	// Write expressions with Expr or a string condition instead.
 	QuoteIdent(id string) string
 
 	EncodeString(s string) string
@@ -12,5 +21,73 @@ type Dialect interface {
 	EncodeTime(t time.Time) string
 	EncodeBytes(b []byte) string
 
+	// Placeholder returns the marker of the bound value n, counted from 0,
+	// e.g. `?` on MySQL, or `$1` for n = 0 on PostgreSQL.
+	// Builders always write `?`, which is replaced with it.
 	Placeholder(n int) string
+
+	// LimitOffset returns the clause of SELECT to return at most limit rows
+	// after skipping offset rows, e.g. `LIMIT 10 OFFSET 20`.
+	// A negative limit or offset is left out, and "" is returned if both are.
+	LimitOffset(limit, offset int64) string
+}
+
+// MaxParamser can be implemented by a Dialect to limit the number of values
+// bound to one statement, like the built-in dialects do.
+// InsertStmt inserts fewer rows per statement than its default
+// if the rows have too many columns for it.
+type MaxParamser interface {
+	MaxParams() int
+}
+
+// maxParams returns the limit of values bound to one statement of d,
+// or 0 if d has none.
+func maxParams(d Dialect) int {
+	if n, ok := d.(noQuote); ok {
+		d = n.Dialect
+	}
+	if m, ok := d.(MaxParamser); ok {
+		return m.MaxParams()
+	}
+	return 0
+}
+
+// isDialect reports whether d is the built-in dialect target.
+func isDialect(d, target Dialect) bool {
+	if n, ok := d.(noQuote); ok {
+		d = n.Dialect
+	}
+	return d == target
+}
+
+type noQuote struct {
+	Dialect
+}
+
+func (noQuote) QuoteIdent(id string) string {
+	return id
+}
+
+// NoQuote returns d without identifier quoting, e.g. `INSERT INTO users (id,name)`.
+// It is meant for previewing statements with GetSQL, like
+// `stmt.GetSQL(dbr.NoQuote(nil))`, and never for executing them.
+// If d is nil, the dialect of the statement is used.
+func NoQuote(d Dialect) Dialect {
+	return noQuote{d}
+}
+
+// previewDialect returns the dialect given to GetSQL, or d if there is none.
+// A statement created without a session, e.g. with the package-level
+// InsertInto, has no dialect, so MySQL is used for it.
+func previewDialect(d Dialect, override []Dialect) Dialect {
+	if d == nil {
+		d = dialect.MySQL
+	}
+	if len(override) == 0 || override[0] == nil {
+		return d
+	}
+	if n, ok := override[0].(noQuote); ok && n.Dialect == nil {
+		return noQuote{d}
+	}
+	return override[0]
 }
@@ -1,6 +1,9 @@
 package dialect
 
-import "strings"
+import (
+	"strconv"
+	"strings"
+)
 
 var (
 	// MySQL dialect
@@ -15,10 +18,29 @@ const (
 	timeFormat = "2006-01-02 15:04:05.000000"
 )
 
+// quoteIdent quotes each segment of a dotted name like `db.users`,
+// doubling the quote characters in it.
+// `*` is returned unchanged; use Expr for function calls.
 func quoteIdent(s, quote string) string {
+	if s == "*" {
+		return s
+	}
 	part := strings.SplitN(s, ".", 2)
 	if len(part) == 2 {
 		return quoteIdent(part[0], quote) + "." + quoteIdent(part[1], quote)
 	}
-	return quote + s + quote
+	return quote + strings.Replace(s, quote, quote+quote, -1) + quote
+}
+
+// limitOffset returns the standard `LIMIT n OFFSET m`, leaving out
+// a negative limit or offset.
+func limitOffset(limit, offset int64) string {
+	var clause []string
+	if limit >= 0 {
+		clause = append(clause, "LIMIT "+strconv.FormatInt(limit, 10))
+	}
+	if offset >= 0 {
+		clause = append(clause, "OFFSET "+strconv.FormatInt(offset, 10))
+	}
+	return strings.Join(clause, " ")
 }
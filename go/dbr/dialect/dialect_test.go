@@ -0,0 +1,26 @@
+package dialect
+
+import "testing"
+
+func TestQuoteIdent(t *testing.T) {
+	for _, test := range []struct {
+		in, mysql, postgres string
+	}{
+		{"users", "`users`", `"users"`},
+		{"db.users", "`db`.`users`", `"db"."users"`},
+		{"*", "*", "*"},
+		{"a`b", "`a``b`", "\"a`b\""},
+		{`a"b`, "`a\"b`", `"a""b"`},
+		{"SLEEP(5)", "`SLEEP(5)`", `"SLEEP(5)"`},
+		{"COUNT(*)", "`COUNT(*)`", `"COUNT(*)"`},
+		{"x) OR (1", "`x) OR (1`", `"x) OR (1"`},
+		{"a`; DROP TABLE t; --", "`a``; DROP TABLE t; --`", "\"a`; DROP TABLE t; --\""},
+	} {
+		if got := MySQL.QuoteIdent(test.in); got != test.mysql {
+			t.Errorf("MySQL.QuoteIdent(%q) = %s, want %s", test.in, got, test.mysql)
+		}
+		if got := PostgreSQL.QuoteIdent(test.in); got != test.postgres {
+			t.Errorf("PostgreSQL.QuoteIdent(%q) = %s, want %s", test.in, got, test.postgres)
+		}
+	}
+}
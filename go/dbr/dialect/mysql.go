@@ -64,3 +64,12 @@ func (d mysql) EncodeBytes(b []byte) string {
 func (d mysql) Placeholder(_ int) string {
 	return "?"
 }
+
+// MaxParams is the placeholder limit of a prepared statement.
+func (d mysql) MaxParams() int {
+	return 65535
+}
+
+func (d mysql) LimitOffset(limit, offset int64) string {
+	return limitOffset(limit, offset)
+}
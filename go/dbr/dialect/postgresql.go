@@ -35,3 +35,12 @@ func (d postgreSQL) EncodeBytes(b []byte) string {
 func (d postgreSQL) Placeholder(n int) string {
 	return fmt.Sprintf("$%d", n+1)
 }
+
+// MaxParams is the limit of bind parameters in the wire protocol.
+func (d postgreSQL) MaxParams() int {
+	return 65535
+}
+
+func (d postgreSQL) LimitOffset(limit, offset int64) string {
+	return limitOffset(limit, offset)
+}
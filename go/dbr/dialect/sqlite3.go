@@ -38,3 +38,12 @@ func (d sqlite3) EncodeBytes(b []byte) string {
 func (d sqlite3) Placeholder(_ int) string {
 	return "?"
 }
+
+// MaxParams is SQLITE_MAX_VARIABLE_NUMBER before SQLite 3.32.0.
+func (d sqlite3) MaxParams() int {
+	return 999
+}
+
+func (d sqlite3) LimitOffset(limit, offset int64) string {
+	return limitOffset(limit, offset)
+}
@@ -0,0 +1,74 @@
+package dbr
+
+import (
+	"testing"
+
+	"github.com/gavin2014/lib/go/dbr/dialect"
+)
+
+func TestNoQuote(t *testing.T) {
+	stmt := InsertInto("users").Columns("id", "name").Values(1, "a")
+	query, err := stmt.GetSQL(NoQuote(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "INSERT INTO users (id,name) VALUES (1,'a')"; query != want {
+		t.Fatalf("GetSQL() = %s, want %s", query, want)
+	}
+
+	sess, db := newTestSession(dialect.PostgreSQL)
+	stmt = sess.InsertInto("users").Columns("id").Values(1)
+	if query, _ := stmt.GetSQL(NoQuote(nil)); query != "INSERT INTO users (id) VALUES (1)" {
+		t.Fatalf("GetSQL() = %s", query)
+	}
+	if _, err := stmt.Exec(); err != nil {
+		t.Fatal(err)
+	}
+	checkQueries(t, db, `INSERT INTO "users" ("id") VALUES (1)`)
+}
+
+func TestGetSQLWithoutSession(t *testing.T) {
+	for _, test := range []struct {
+		getSQL func(...Dialect) (string, error)
+		query  string
+	}{
+		{InsertInto("users").Columns("id").Values(1).GetSQL, "INSERT INTO `users` (`id`) VALUES (1)"},
+		{Select("id").From("users").Where(Eq("id", 1)).GetSQL, "SELECT id FROM users WHERE (`id` = 1)"},
+		{Update("users").Set("name", "a").Where(Eq("id", 1)).GetSQL, "UPDATE `users` SET `name` = 'a' WHERE (`id` = 1)"},
+		{DeleteFrom("users").Where(Eq("id", 1)).GetSQL, "DELETE FROM `users` WHERE (`id` = 1)"},
+	} {
+		query, err := test.getSQL()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if query != test.query {
+			t.Errorf("GetSQL() = %s, want %s", query, test.query)
+		}
+	}
+	query, err := InsertInto("users").Columns("id").Values(1).GetSQL(dialect.PostgreSQL)
+	if want := `INSERT INTO "users" ("id") VALUES (1)`; err != nil || query != want {
+		t.Fatalf("GetSQL(PostgreSQL) = %s, %v, want %s", query, err, want)
+	}
+}
+
+func TestQuoteIdentFunctionCall(t *testing.T) {
+	// function calls are quoted as names, and written with Expr
+	// or a string column or condition instead
+	checkBuild(t, Select("a").From("t").Where(Eq("COUNT(*)", 1)), dialect.MySQL,
+		"SELECT a FROM t WHERE (`COUNT(*)` = ?)", 1)
+
+	sess, db := newTestSession(dialect.MySQL)
+	var n []int
+	_, err := sess.Select("a", "COUNT(*)").From("t").GroupBy("a").Having(Expr("COUNT(*) > ?", 1)).Load(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = sess.Update("t").Set("n", Expr("COALESCE(n, 0) + ?", 1)).Where(Eq("id", 1)).Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkQueries(t, db,
+		"SELECT a, COUNT(*) FROM t GROUP BY a HAVING (COUNT(*) > 1)",
+		"UPDATE `t` SET `n` = COALESCE(n, 0) + 1 WHERE (`id` = 1)",
+	)
+}
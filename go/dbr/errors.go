@@ -13,4 +13,57 @@ var (
 	ErrInvalidSliceLength = errors.New("dbr: length of slice is 0. length must be >= 1")
 	ErrCantConvertToTime  = errors.New("dbr: can't convert to time.Time")
 	ErrInvalidTimestring  = errors.New("dbr: invalid time string")
+	ErrValueCount         = errors.New("dbr: number of values does not match columns")
+	ErrDryRun             = errors.New("dbr: rows are not available in dry-run mode")
+	ErrBufferClosed       = errors.New("dbr: insert buffer is closed")
+	ErrCursorNotInTx      = errors.New("dbr: cursor must be declared in a transaction")
+	ErrInvalidFilter      = errors.New("dbr: invalid filter")
+	ErrPairMultipleRows   = errors.New("dbr: pair only allows one record to insert")
+	ErrWhereNotSpecified  = errors.New("dbr: where condition not specified")
+	ErrInvalidSort        = errors.New("dbr: invalid sort")
+	ErrMultipleRows       = errors.New("dbr: more than one row returned")
+	ErrPoolTooSmall       = errors.New("dbr: pool allows fewer open connections")
 )
+
+// QueryError is returned when a statement cannot be interpolated,
+// or the database fails it, by Exec, Load, and the other methods
+// running a statement.
+// It carries the SQL of the statement with placeholders, and the args
+// bound to them, so that the values are not part of SQL or Error.
+// Use errors.Is or errors.As to check the underlying error.
+type QueryError struct {
+	sql  string
+	args []interface{}
+	err  error
+}
+
+// newQueryError wraps err, the error of a failed statement, in a QueryError.
+// err can be nil if an EventReceiver returned nil from EventErrKv,
+// and the statement is still reported as failed.
+func newQueryError(query string, args []interface{}, err error) error {
+	return &QueryError{sql: query, args: args, err: err}
+}
+
+func (e *QueryError) Error() string {
+	if e.err == nil {
+		return "dbr: query failed"
+	}
+	return e.err.Error()
+}
+
+// SQL returns the statement that failed, with placeholders for its args.
+func (e *QueryError) SQL() string {
+	return e.sql
+}
+
+// Args returns the args of the statement that failed,
+// which may hold sensitive values unless the session redacts them,
+// see Session.RedactArgs.
+func (e *QueryError) Args() []interface{} {
+	return e.args
+}
+
+// Unwrap returns the underlying error.
+func (e *QueryError) Unwrap() error {
+	return e.err
+}
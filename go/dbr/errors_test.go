@@ -0,0 +1,106 @@
+package dbr
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/gavin2014/lib/go/dbr/dialect"
+)
+
+func checkQueryError(t *testing.T, err error, target error, query string, args ...interface{}) {
+	t.Helper()
+	var qe *QueryError
+	if !errors.As(err, &qe) {
+		t.Fatalf("error %v is not a QueryError", err)
+	}
+	if !errors.Is(err, target) {
+		t.Errorf("error %v is not %v", err, target)
+	}
+	if qe.SQL() != query {
+		t.Errorf("SQL() = %s, want %s", qe.SQL(), query)
+	}
+	if !reflect.DeepEqual(qe.Args(), args) {
+		t.Errorf("Args() = %v, want %v", qe.Args(), args)
+	}
+}
+
+func TestQueryError(t *testing.T) {
+	errFail := errors.New("fail")
+	sess, db := newTestSession(dialect.MySQL)
+	db.failOn("DELETE FROM `t` WHERE (secret = 'x')", errFail)
+	db.failOn("SELECT a FROM t WHERE (secret = 'x')", errFail)
+
+	_, err := sess.DeleteFrom("t").Where("secret = ?", "x").Exec()
+	checkQueryError(t, err, errFail, "DELETE FROM `t` WHERE (secret = ?)", "x")
+	if err.Error() != "fail" {
+		t.Errorf("Error() = %s, want the driver error", err)
+	}
+
+	var a []int
+	_, err = sess.Select("a").From("t").Where("secret = ?", "x").Load(&a)
+	checkQueryError(t, err, errFail, "SELECT a FROM t WHERE (secret = ?)", "x")
+
+	_, err = sess.Select("a").From("t").Where("b = ? AND c = ?", 1).Load(&a)
+	checkQueryError(t, err, ErrPlaceholderCount, "SELECT a FROM t WHERE (b = ? AND c = ?)", 1)
+}
+
+func TestRedactArgs(t *testing.T) {
+	for _, redact := range []bool{false, true} {
+		arg, kv := interface{}("x"), "[x 1]"
+		if redact {
+			arg, kv = redacted, "[[redacted] [redacted]]"
+		}
+		errFail := errors.New("fail")
+		sess, db := newTestSession(dialect.MySQL)
+		rec := &RecordingEventReceiver{}
+		sess.EventReceiver = rec
+		sess.CacheStatements(true)
+		sess.RedactArgs(redact)
+		db.failOn("DELETE FROM `t` WHERE (secret = ?)", errFail)
+
+		_, err := sess.DeleteFrom("t").Where("secret = ?", "x").Exec()
+		checkQueryError(t, err, errFail, "DELETE FROM `t` WHERE (secret = ?)", arg)
+		if err.Error() != "fail" {
+			t.Errorf("Error() = %s, want the driver error", err)
+		}
+
+		// a Tx redacts like its session
+		tx, err := sess.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tx.Update("t").Set("secret", "x").Where("id = ?", 1).Exec(); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		var args []string
+		for _, e := range rec.Events() {
+			if e.Name == "dbr.exec" {
+				args = append(args, e.Args)
+			}
+		}
+		if len(args) != 1 || args[0] != kv {
+			t.Errorf("redact %v: event args = %q, want %s", redact, args, kv)
+		}
+	}
+}
+
+// swallowingReceiver returns nil from EventErrKv.
+type swallowingReceiver struct {
+	NullEventReceiver
+}
+
+func (*swallowingReceiver) EventErrKv(string, error, map[string]string) error { return nil }
+
+func TestQueryErrorNil(t *testing.T) {
+	sess, db := newTestSession(dialect.MySQL)
+	sess.EventReceiver = &swallowingReceiver{}
+	db.failOn("DELETE FROM `t`", errors.New("fail"))
+	_, err := sess.DeleteFrom("t").Exec()
+	if err == nil || err.Error() != "dbr: query failed" {
+		t.Fatalf("Exec() = %v, want a failed query", err)
+	}
+}
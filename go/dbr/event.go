@@ -3,7 +3,9 @@ package dbr
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -39,10 +41,181 @@ func ShowSQL(level int, logPrint ...func(args ...interface{})) {
 	}
 }
 
+const defaultLogLayout = "2006/01/02 15:04:05.000"
+
+var (
+	logClock    = time.Now
+	logLayout   = defaultLogLayout
+	logLocation *time.Location
+)
+
+// LogClock sets the clock of the timestamps printed by ShowSQL
+// without a logPrint function,
+// e.g. to stub the time in tests. nil restores time.Now.
+func LogClock(now func() time.Time) {
+	if now == nil {
+		now = time.Now
+	}
+	logClock = now
+}
+
+// LogTimeFormat sets the layout and location of the timestamps printed
+// by ShowSQL, e.g. `LogTimeFormat(time.RFC3339Nano, time.UTC)`.
+// An empty layout keeps the default "2006/01/02 15:04:05.000",
+// and a nil location keeps the local time of the clock.
+func LogTimeFormat(layout string, loc *time.Location) {
+	if layout == "" {
+		layout = defaultLogLayout
+	}
+	logLayout = layout
+	logLocation = loc
+}
+
+// logTimestamp formats the time d before now for ShowSQL,
+// i.e. when a statement that took d started.
+func logTimestamp(d time.Duration) string {
+	t := logClock().Add(-d)
+	if logLocation != nil {
+		t = t.In(logLocation)
+	}
+	return t.Format(logLayout)
+}
+
+var labelComment bool
+
+// CommentLabel sets whether the label of a statement is also written
+// as a leading SQL comment, e.g. `/* users.list */ SELECT ...`,
+// so it shows up in the slow query log of the database.
+func CommentLabel(enable bool) {
+	labelComment = enable
+}
+
 type kvs map[string]string
 
+// builderLabel returns the label set on a statement with Label.
+func builderLabel(builder Builder) string {
+	switch b := builder.(type) {
+	case *SelectStmt:
+		return b.QueryLabel
+	case *InsertStmt:
+		return b.QueryLabel
+	case *UpdateStmt:
+		return b.QueryLabel
+	case *DeleteStmt:
+		return b.QueryLabel
+	case *CaseUpdateStmt:
+		return b.QueryLabel
+	}
+	return ""
+}
+
+type logFieldsKey struct{}
+
+// WithLogFields returns a copy of ctx carrying fields, e.g. a request id.
+// The fields are added to the kvs of events from statements run with
+// the context, without replacing the kvs set by dbr like "sql".
+// Fields already in ctx are kept unless fields replaces them.
+func WithLogFields(ctx context.Context, fields map[string]string) context.Context {
+	merged := make(map[string]string)
+	if parent, ok := ctx.Value(logFieldsKey{}).(map[string]string); ok {
+		for k, v := range parent {
+			merged[k] = v
+		}
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, logFieldsKey{}, merged)
+}
+
+// redacted replaces the args in errors and events if RedactArgs is set.
+const redacted = "[redacted]"
+
+// logArgs returns args as they are reported in errors and events of runner.
+func logArgs(runner runner, args []interface{}) []interface{} {
+	if len(args) == 0 || !runner.GetRedactArgs() {
+		return args
+	}
+	r := make([]interface{}, len(args))
+	for i := range r {
+		r[i] = redacted
+	}
+	return r
+}
+
+// queryKvs returns the kvs of a statement run with query.
+// The args are added if values are bound to its placeholders,
+// e.g. with CacheStatements, rather than interpolated into it.
+func queryKvs(query string, value []interface{}) kvs {
+	kv := kvs{"sql": query}
+	if len(value) > 0 {
+		kv["args"] = fmt.Sprint(value)
+	}
+	return kv
+}
+
+// eventKvs adds the label of builder and the log fields in ctx to kv.
+func eventKvs(ctx context.Context, builder Builder, kv kvs) kvs {
+	if label := builderLabel(builder); label != "" {
+		kv["label"] = label
+	}
+	if fields, ok := ctx.Value(logFieldsKey{}).(map[string]string); ok {
+		for k, v := range fields {
+			if _, ok := kv[k]; !ok {
+				kv[k] = v
+			}
+		}
+	}
+	return kv
+}
+
+// labelQuery prepends the label of builder to query if CommentLabel is enabled.
+func labelQuery(builder Builder, query string) string {
+	if !labelComment {
+		return query
+	}
+	label := builderLabel(builder)
+	if label == "" {
+		return query
+	}
+	return "/* " + strings.Replace(label, "*/", "* /", -1) + " */ " + query
+}
+
 var nullReceiver = &NullEventReceiver{}
 
+// timingEnabled reports whether log uses the kvs of TimingKv.
+// A NullEventReceiver ignores them unless ShowSQL is 2,
+// so the kvs are not built on the hot path when logging is off.
+func timingEnabled(log EventReceiver) bool {
+	switch log.(type) {
+	case *NullEventReceiver:
+		return showSQLLevel >= 2
+	case timingMuted:
+		return false
+	}
+	return true
+}
+
+// timingMuted drops the timing events of an EventReceiver,
+// but keeps its other events and its spans.
+type timingMuted struct {
+	EventReceiver
+}
+
+// tracingReceiver returns log as a TracingEventReceiver if it is one,
+// also when its timing events are muted.
+func tracingReceiver(log EventReceiver) (TracingEventReceiver, bool) {
+	if m, ok := log.(timingMuted); ok {
+		log = m.EventReceiver
+	}
+	t, ok := log.(TracingEventReceiver)
+	return t, ok
+}
+
+func (timingMuted) Timing(eventName string, nanoseconds int64) {}
+
+func (timingMuted) TimingKv(eventName string, nanoseconds int64, kvs map[string]string) {}
+
 // NullEventReceiver is a sentinel EventReceiver.
 // Use it if the caller doesn't supply one.
 type NullEventReceiver struct{}
@@ -78,7 +251,7 @@ func (n *NullEventReceiver) EventErrKv(eventName string, err error, kvs map[stri
 		if logPrintFunc != nil {
 			logPrintFunc(sqlLog)
 		} else {
-			fmt.Println(fmt.Sprintf("[DBR]%s %s", time.Now().Add(-use).Format("2006/01/02 15:04:05.000"), sqlLog))
+			fmt.Println(fmt.Sprintf("[DBR]%s %s", logTimestamp(use), sqlLog))
 		}
 	}
 	return err
@@ -88,17 +261,37 @@ func (n *NullEventReceiver) EventErrKv(eventName string, err error, kvs map[stri
 func (n *NullEventReceiver) Timing(eventName string, nanoseconds int64) {}
 
 // TimingKv receives the time an event took to happen along with optional key/value data.
+// Events without SQL like "dbr.insert.batch" are printed with their kvs.
 func (n *NullEventReceiver) TimingKv(eventName string, nanoseconds int64, kvs map[string]string) {
 	if showSQLLevel >= 2 {
 		var sql string
 		if s, ok := kvs["sql"]; ok {
 			sql = s
+		} else {
+			sql = eventName + formatKvs(kvs)
 		}
 		sqlLog := fmt.Sprintf("[OK %dms] %s", nanoseconds/1e6, sql)
 		if logPrintFunc != nil {
 			logPrintFunc(sqlLog)
 		} else {
-			fmt.Println(fmt.Sprintf("[DBR]%s %s", time.Now().Add(-time.Duration(nanoseconds)).Format("2006/01/02 15:04:05.000"), sqlLog))
+			fmt.Println(fmt.Sprintf("[DBR]%s %s", logTimestamp(time.Duration(nanoseconds)), sqlLog))
 		}
 	}
 }
+
+// formatKvs formats kvs as ` k1=v1 k2=v2` sorted by key.
+func formatKvs(kvs map[string]string) string {
+	key := make([]string, 0, len(kvs))
+	for k := range kvs {
+		key = append(key, k)
+	}
+	sort.Strings(key)
+	var buf strings.Builder
+	for _, k := range key {
+		buf.WriteString(" ")
+		buf.WriteString(k)
+		buf.WriteString("=")
+		buf.WriteString(kvs[k])
+	}
+	return buf.String()
+}
@@ -0,0 +1,142 @@
+package dbr
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gavin2014/lib/go/dbr/dialect"
+)
+
+func TestLabel(t *testing.T) {
+	sess, db := newTestSession(dialect.MySQL)
+	rec := &RecordingEventReceiver{}
+	sess.EventReceiver = rec
+	_, err := sess.Update("users").Set("name", "a").Where("id = ?", 1).Label("users.rename").Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := rec.Events()
+	if len(e) != 1 || e[0].Kvs["label"] != "users.rename" {
+		t.Fatalf("events = %+v", e)
+	}
+
+	CommentLabel(true)
+	defer CommentLabel(false)
+	_, err = sess.DeleteFrom("users").Where("id = ?", 1).Label("users.delete */ DROP").Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkQueries(t, db,
+		"UPDATE `users` SET `name` = 'a' WHERE (id = 1)",
+		"/* users.delete * / DROP */ DELETE FROM `users` WHERE (id = 1)",
+	)
+}
+
+func TestWithLogFields(t *testing.T) {
+	sess, db := newTestSession(dialect.MySQL)
+	rec := &RecordingEventReceiver{}
+	sess.EventReceiver = rec
+	ctx := WithLogFields(context.Background(), map[string]string{"request_id": "r1", "user": "a"})
+	ctx = WithLogFields(ctx, map[string]string{"user": "b", "sql": "ignored"})
+
+	_, err := sess.Update("users").Set("name", "a").Where("id = ?", 1).ExecContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.failOn("DELETE FROM `users` WHERE (id = 1)", errors.New("fail"))
+	sess.DeleteFrom("users").Where("id = ?", 1).ExecContext(ctx)
+
+	e := rec.Events()
+	if len(e) != 2 {
+		t.Fatalf("events = %+v", e)
+	}
+	for i, sql := range []string{"UPDATE `users` SET `name` = 'a' WHERE (id = 1)", "DELETE FROM `users` WHERE (id = 1)"} {
+		kv := e[i].Kvs
+		if kv["request_id"] != "r1" || kv["user"] != "b" || kv["sql"] != sql {
+			t.Errorf("%s kvs = %v, want the log fields and the sql", e[i].Name, kv)
+		}
+	}
+}
+
+func benchmarkExecLogging(b *testing.B, level int) {
+	defer ShowSQL(showSQLLevel, logPrintFunc)
+	ShowSQL(level, func(...interface{}) {})
+	sess, _ := newTestSession(dialect.MySQL)
+	stmt := sess.Update("users").Set("name", "a").Where("id = ?", 1)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := stmt.Exec(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkExecLoggingOff(b *testing.B) { benchmarkExecLogging(b, 0) }
+
+func BenchmarkExecLoggingOn(b *testing.B) { benchmarkExecLogging(b, 2) }
+
+func TestExecLoggingOffSkipsKvs(t *testing.T) {
+	allocs := func(level int) float64 {
+		defer ShowSQL(showSQLLevel, logPrintFunc)
+		ShowSQL(level, func(...interface{}) {})
+		sess, _ := newTestSession(dialect.MySQL)
+		stmt := sess.Update("users").Set("name", "a").Where("id = ?", 1)
+		return testing.AllocsPerRun(100, func() {
+			stmt.Exec()
+		})
+	}
+	if off, on := allocs(0), allocs(2); off >= on {
+		t.Fatalf("%v allocs with logging off, %v with logging on", off, on)
+	}
+}
+
+type tracingRecorder struct {
+	RecordingEventReceiver
+	spans []string
+}
+
+func (r *tracingRecorder) SpanStart(ctx context.Context, eventName, query string) context.Context {
+	r.spans = append(r.spans, eventName+": "+query)
+	return ctx
+}
+
+func (r *tracingRecorder) SpanError(ctx context.Context, err error) {}
+
+func (r *tracingRecorder) SpanFinish(ctx context.Context) {}
+
+func TestSuppressChunkEventsKeepsSpans(t *testing.T) {
+	sess, _ := newTestSession(dialect.MySQL)
+	rec := &tracingRecorder{}
+	sess.EventReceiver = rec
+	sess.SuppressChunkEvents(true)
+	stmt := sess.InsertInto("t").Columns("a").SetRunLen(1).Values(1).Values(2)
+	if _, err := stmt.Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.spans) != 2 {
+		t.Fatalf("spans = %q, want one per chunk", rec.spans)
+	}
+	e := rec.Events()
+	if len(e) != 1 || e[0].Name != "dbr.insert.batch" || e[0].Kvs["chunks"] != "2" {
+		t.Fatalf("events = %+v, want only dbr.insert.batch", e)
+	}
+}
+
+func TestLogTimestamp(t *testing.T) {
+	defer LogClock(nil)
+	defer LogTimeFormat("", nil)
+	now := time.Date(2024, 1, 2, 3, 4, 5, 600000000, time.FixedZone("CET", 3600))
+	LogClock(func() time.Time { return now })
+
+	// the time the statement started
+	LogTimeFormat("", nil)
+	if got, want := logTimestamp(2*time.Second), "2024/01/02 03:04:03.600"; got != want {
+		t.Errorf("logTimestamp() = %s, want %s", got, want)
+	}
+	LogTimeFormat(time.RFC3339, time.UTC)
+	if got, want := logTimestamp(0), "2024-01-02T02:04:05Z"; got != want {
+		t.Errorf("logTimestamp() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,110 @@
+package dbr
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FieldSpec describes a field that can be filtered with ParseFilters.
+type FieldSpec struct {
+	// Column is the column to filter on. The field name is used if it is empty.
+	Column string
+	// Op lists the allowed operators: eq, ne, gt, gte, lt, lte, in, and like.
+	// Only eq is allowed if it is empty.
+	Op []string
+}
+
+func (spec FieldSpec) allow(op string) bool {
+	if len(spec.Op) == 0 {
+		return op == "eq"
+	}
+	for _, allowed := range spec.Op {
+		if allowed == op {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseFilters translates query parameters like `status=active&age__gte=18`
+// into conditions joined with AND.
+// The operator is given as a suffix of the field: __ne, __gt, __gte, __lt,
+// __lte, __in (with comma-separated values), and __like.
+// Without a suffix, the field is compared with `=`, or `IN` if it has
+// multiple values.
+//
+// Only fields in allowed with an allowed operator can be used,
+// and ErrInvalidFilter is returned for any other parameter.
+// Values are always bound, never written into SQL.
+// If params has no filter, nil is returned, which Where ignores.
+func ParseFilters(params map[string][]string, allowed map[string]FieldSpec) (Builder, error) {
+	key := make([]string, 0, len(params))
+	for k := range params {
+		key = append(key, k)
+	}
+	// keep the conditions in a stable order
+	sort.Strings(key)
+
+	var cond []Builder
+	for _, k := range key {
+		value := params[k]
+		if len(value) == 0 {
+			continue
+		}
+		field, op := k, "eq"
+		if i := strings.LastIndex(k, "__"); i >= 0 {
+			field, op = k[:i], k[i+2:]
+		}
+		spec, ok := allowed[field]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidFilter, field)
+		}
+		if !spec.allow(op) {
+			return nil, fmt.Errorf("%w: operator %q is not allowed on %q", ErrInvalidFilter, op, field)
+		}
+		column := spec.Column
+		if column == "" {
+			column = field
+		}
+		c, err := buildFilter(column, op, value)
+		if err != nil {
+			return nil, err
+		}
+		cond = append(cond, c)
+	}
+	if len(cond) == 0 {
+		return nil, nil
+	}
+	return And(cond...), nil
+}
+
+func buildFilter(column, op string, value []string) (Builder, error) {
+	last := value[len(value)-1]
+	switch op {
+	case "eq":
+		if len(value) > 1 {
+			return Eq(column, value), nil
+		}
+		return Eq(column, last), nil
+	case "ne":
+		return Neq(column, last), nil
+	case "gt":
+		return Gt(column, last), nil
+	case "gte":
+		return Gte(column, last), nil
+	case "lt":
+		return Lt(column, last), nil
+	case "lte":
+		return Lte(column, last), nil
+	case "in":
+		var list []string
+		for _, v := range value {
+			list = append(list, strings.Split(v, ",")...)
+		}
+		return Eq(column, list), nil
+	case "like":
+		return Like(column, last), nil
+	}
+	return nil, fmt.Errorf("%w: unknown operator %q", ErrInvalidFilter, op)
+}
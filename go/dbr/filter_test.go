@@ -0,0 +1,43 @@
+package dbr
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gavin2014/lib/go/dbr/dialect"
+)
+
+func TestParseFilters(t *testing.T) {
+	allowed := map[string]FieldSpec{
+		"status": {},
+		"age":    {Column: "users.age", Op: []string{"gte", "lt"}},
+		"id":     {Op: []string{"in"}},
+	}
+	cond, err := ParseFilters(map[string][]string{
+		"status":   {"active"},
+		"age__gte": {"18"},
+		"id__in":   {"1,2", "3"},
+	}, allowed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkBuild(t, cond, dialect.MySQL,
+		"(`users`.`age` >= ?) AND (`id` IN ?) AND (`status` = ?)",
+		"18", []string{"1", "2", "3"}, "active")
+
+	for _, params := range []map[string][]string{
+		{"password": {"x"}},
+		{"age": {"18"}},
+		{"status__like": {"a%"}},
+		{"age__gte": {"1"}, "name": {"x"}},
+	} {
+		if _, err := ParseFilters(params, allowed); !errors.Is(err, ErrInvalidFilter) {
+			t.Errorf("ParseFilters(%v) = %v, want ErrInvalidFilter", params, err)
+		}
+	}
+
+	cond, err = ParseFilters(nil, allowed)
+	if cond != nil || err != nil {
+		t.Fatalf("ParseFilters(nil) = %v, %v", cond, err)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !dbrdebug
+// +build !dbrdebug
+
+package dbr
+
+// guard detects concurrent use of a statement whose Build consumes
+// its values, like InsertStmt. It does nothing unless dbr is built
+// with the dbrdebug tag, e.g. `go test -race -tags dbrdebug ./...`.
+// Exec, Load, GetSQL, Prepare, and Session.ExecBatch are guarded,
+// but Build is not, as they call it.
+type guard struct{}
+
+func (*guard) enter(stmt string) {}
+
+func (*guard) leave() {}
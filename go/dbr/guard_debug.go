@@ -0,0 +1,22 @@
+//go:build dbrdebug
+// +build dbrdebug
+
+package dbr
+
+import "sync/atomic"
+
+// guard panics if a statement is executed by a goroutine
+// while another one is still executing it.
+type guard struct {
+	busy int32
+}
+
+func (g *guard) enter(stmt string) {
+	if !atomic.CompareAndSwapInt32(&g.busy, 0, 1) {
+		panic("dbr: concurrent use of " + stmt + ", which is not safe for concurrent use")
+	}
+}
+
+func (g *guard) leave() {
+	atomic.StoreInt32(&g.busy, 0)
+}
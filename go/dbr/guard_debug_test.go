@@ -0,0 +1,37 @@
+//go:build dbrdebug
+// +build dbrdebug
+
+package dbr
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gavin2014/lib/go/dbr/dialect"
+)
+
+func TestGuardPanics(t *testing.T) {
+	for name, use := range map[string]func(*Session, *InsertStmt){
+		"Exec":      func(_ *Session, stmt *InsertStmt) { stmt.Exec() },
+		"GetSQL":    func(_ *Session, stmt *InsertStmt) { stmt.GetSQL() },
+		"Prepare":   func(_ *Session, stmt *InsertStmt) { stmt.Prepare() },
+		"ExecBatch": func(sess *Session, stmt *InsertStmt) { sess.ExecBatch(context.Background(), stmt) },
+	} {
+		sess, db := newTestSession(dialect.MySQL)
+		stmt := sess.InsertInto("t").Columns("a").Values(1)
+		var recovered interface{}
+		// the statement is used again while Exec is running it
+		db.onExec = func(string) {
+			db.onExec = nil
+			defer func() { recovered = recover() }()
+			use(sess, stmt)
+		}
+		if _, err := stmt.Exec(); err != nil {
+			t.Fatal(err)
+		}
+		if recovered == nil {
+			t.Errorf("%s during Exec did not panic", name)
+		}
+		stmt.GetSQL() // panics unless Exec released the guard
+	}
+}
@@ -3,8 +3,14 @@ package dbr
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/gavin2014/lib/go/dbr/dialect"
 )
 
 // InsertStmt builds `INSERT INTO ...`.
@@ -19,15 +25,42 @@ type InsertStmt struct {
 	RunLen       int
 	ReturnColumn []string
 	RecordID     *int64
+	Partition    PartitionFunc
+	QueryLabel   string
+	TableMapper  func(string) string
+
+	guard       guard
+	onBatch     func(chunk, rowsSoFar int)
+	quietChunks bool
+	replace     bool
+	omitZero    bool
+	onDup       []dupUpdate
+	onConflict  bool
+	idColumn    string
+	mapColumns  bool
+	conflict    []string
+	returnInto  returnLoader
+	with        withClause
+	err         error
+}
+
+// dupUpdate is a column to set in `ON DUPLICATE KEY UPDATE`.
+type dupUpdate struct {
+	column string
+	value  interface{}
 }
 
 type InsertBuilder = InsertStmt
 
+// defaultRunLen is the number of rows inserted per statement
+// when neither the statement nor its session sets one.
+const defaultRunLen = 1000
+
 func (b *InsertStmt) Build(d Dialect, buf Buffer) error {
-	//赋予批量插入默认最大上限
-	if b.RunLen==0{
-		b.RunLen=1000
+	if b.err != nil {
+		return b.err
 	}
+
 	if b.raw.Query != "" {
 		return b.raw.Build(d, buf)
 	}
@@ -40,13 +73,58 @@ func (b *InsertStmt) Build(d Dialect, buf Buffer) error {
 		return ErrColumnNotSpecified
 	}
 
-	buf.WriteString("INSERT INTO ")
-	buf.WriteString(d.QuoteIdent(b.Table))
+	//超过更新行数
+	runnum := b.chunkLen(d, b.Value)
+	if err := b.checkValues(b.Value[:runnum]); err != nil {
+		return err
+	}
+	// every row of the chunk omits the same columns
+	var omit []bool
+	if runnum > 0 {
+		omit = omitMask(b.Value[0])
+	}
+	column := b.Column
+	if omit != nil {
+		column = nil
+		for i, col := range b.Column {
+			if !omit[i] {
+				column = append(column, col)
+			}
+		}
+		if len(column) == 0 {
+			return ErrColumnNotSpecified
+		}
+	}
+
+	verb := "INSERT INTO "
+	if b.replace {
+		switch {
+		case isDialect(d, dialect.MySQL):
+			verb = "REPLACE INTO "
+		case isDialect(d, dialect.SQLite3):
+			verb = "INSERT OR REPLACE INTO "
+		default:
+			return fmt.Errorf("%w: REPLACE INTO, use ON CONFLICT instead", ErrNotSupported)
+		}
+	}
+
+	if len(b.with.cte) > 0 && isDialect(d, dialect.MySQL) {
+		return fmt.Errorf("%w: WITH before INSERT, use INSERT ... SELECT instead", ErrNotSupported)
+	}
+	if len(b.with.cte) > 0 && runnum < len(b.Value) {
+		return errWithChunks
+	}
+	if err := b.with.Build(d, buf); err != nil {
+		return err
+	}
+
+	buf.WriteString(verb)
+	buf.WriteString(d.QuoteIdent(mapTable(b.TableMapper, b.Table)))
 
 	var placeholderBuf strings.Builder
 	placeholderBuf.WriteString("(")
 	buf.WriteString(" (")
-	for i, col := range b.Column {
+	for i, col := range column {
 		if i > 0 {
 			buf.WriteString(",")
 			placeholderBuf.WriteString(",")
@@ -57,26 +135,75 @@ func (b *InsertStmt) Build(d Dialect, buf Buffer) error {
 	buf.WriteString(") VALUES ")
 	placeholderBuf.WriteString(")")
 	placeholderStr := placeholderBuf.String()
-	var runnum int
-	for i, tuple := range b.Value {
 
-		//超过更新行数
-		if i>=b.RunLen{
-			break
-		}
-		//更新数量
-		runnum++
+	// write the whole VALUES segment and its values at once
+	// to keep large batches from growing the buffers row by row.
+	var valuesBuf strings.Builder
+	valuesBuf.Grow(runnum * (len(placeholderStr) + 2))
+	value := make([]interface{}, 0, runnum*len(column))
+	for i, tuple := range b.Value[:runnum] {
 		if i > 0 {
-			buf.WriteString(", ")
+			valuesBuf.WriteString(", ")
+		}
+		if omit != nil {
+			tuple = dropOmitted(tuple)
+		}
+		if !hasBuilder(tuple) {
+			valuesBuf.WriteString(placeholderStr)
+			value = append(value, tuple...)
+			continue
+		}
+		var err error
+		value, err = buildTuple(d, &valuesBuf, value, tuple)
+		if err != nil {
+			return err
 		}
-		buf.WriteString(placeholderStr)
-		buf.WriteValue(tuple...)
+	}
+	buf.WriteString(valuesBuf.String())
+	buf.WriteValue(value...)
+	returning := b.ReturnColumn
+	b.returnInto = nil
+	if len(returning) == 0 {
+		returning, b.returnInto = b.generatedReturning(d, b.Value[:runnum])
 	}
 	//进行截取
-	b.Value=b.Value[runnum:]
-	if len(b.ReturnColumn) > 0 {
+	b.Value = b.Value[runnum:]
+	if b.onConflict {
+		if isDialect(d, dialect.MySQL) {
+			return fmt.Errorf("%w: ON CONFLICT, use OnDupKeyUpdate instead", ErrNotSupported)
+		}
+		buf.WriteString(" ON CONFLICT")
+		if len(b.conflict) > 0 {
+			buf.WriteString(" (")
+			for i, col := range b.conflict {
+				if i > 0 {
+					buf.WriteString(",")
+				}
+				buf.WriteString(d.QuoteIdent(col))
+			}
+			buf.WriteString(")")
+		}
+		if len(b.onDup) == 0 {
+			buf.WriteString(" DO NOTHING")
+		} else if len(b.conflict) == 0 {
+			return fmt.Errorf("%w: ON CONFLICT DO UPDATE needs a conflict target", ErrColumnNotSpecified)
+		} else {
+			buf.WriteString(" DO UPDATE SET ")
+			b.buildDupSet(d, buf)
+		}
+	} else if len(b.onDup) > 0 {
+		if !isDialect(d, dialect.MySQL) {
+			return fmt.Errorf("%w: ON DUPLICATE KEY UPDATE, use OnConflict instead", ErrNotSupported)
+		}
+		if len(returning) > 0 {
+			return fmt.Errorf("%w: RETURNING with ON DUPLICATE KEY UPDATE, load the row by LAST_INSERT_ID() instead", ErrNotSupported)
+		}
+		buf.WriteString(" ON DUPLICATE KEY UPDATE ")
+		b.buildDupSet(d, buf)
+	}
+	if len(returning) > 0 {
 		buf.WriteString(" RETURNING ")
-		for i, col := range b.ReturnColumn {
+		for i, col := range returning {
 			if i > 0 {
 				buf.WriteString(",")
 			}
@@ -87,6 +214,261 @@ func (b *InsertStmt) Build(d Dialect, buf Buffer) error {
 	return nil
 }
 
+// buildDupSet writes the columns set by OnDupKeyUpdate or DoUpdate.
+func (b *InsertStmt) buildDupSet(d Dialect, buf Buffer) {
+	for i, u := range b.onDup {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(d.QuoteIdent(u.column))
+		buf.WriteString(" = ")
+		buf.WriteString(placeholder)
+		buf.WriteValue(u.value)
+	}
+}
+
+// generatedReturning returns the generated columns of rows to be written
+// with RETURNING, and the pointers to scan each returned row into.
+// It returns nil unless d is postgres, as other databases report
+// a generated id with LastInsertId.
+// The n-th returned row is scanned for the n-th row, so nothing is
+// returned with OnConflict, which leaves out the rows in conflict.
+func (b *InsertStmt) generatedReturning(d Dialect, rows [][]interface{}) ([]string, returnLoader) {
+	if len(rows) == 0 || b.onConflict || !isDialect(d, dialect.PostgreSQL) {
+		return nil, nil
+	}
+	var column []string
+	for i, v := range rows[0] {
+		if omitKind(v) == 2 {
+			column = append(column, b.Column[i])
+		}
+	}
+	if column == nil {
+		return nil, nil
+	}
+	into := make(returnLoader, len(rows))
+	for n, tuple := range rows {
+		for _, v := range tuple {
+			if g, ok := v.(generatedColumn); ok {
+				ptr := g.ptr
+				if ptr == nil {
+					ptr = new(interface{})
+				}
+				into[n] = append(into[n], ptr)
+			}
+		}
+	}
+	return column, into
+}
+
+// capturesGenerated reports whether the next chunk is inserted
+// with RETURNING to scan its generated columns.
+func (b *InsertStmt) capturesGenerated() bool {
+	if len(b.ReturnColumn) > 0 || len(b.Value) == 0 || b.onConflict || !isDialect(b.Dialect, dialect.PostgreSQL) {
+		return false
+	}
+	for _, v := range b.Value[0] {
+		if omitKind(v) == 2 {
+			return true
+		}
+	}
+	return false
+}
+
+// rowsResult is the result of a chunk inserted with RETURNING,
+// which is counted from the returned rows.
+type rowsResult int64
+
+func (r rowsResult) LastInsertId() (int64, error) {
+	return 0, fmt.Errorf("%w: LastInsertId with RETURNING", ErrNotSupported)
+}
+
+func (r rowsResult) RowsAffected() (int64, error) {
+	return int64(r), nil
+}
+
+// runLen returns the maximum number of rows inserted per statement.
+// Unless RunLen is set, it is lowered for wide rows so a statement
+// binds no more values than d allows, if it implements MaxParamser.
+func (b *InsertStmt) runLen(d Dialect) int {
+	//赋予批量插入默认最大上限
+	if b.RunLen > 0 {
+		return b.RunLen
+	}
+	n := defaultRunLen
+	if max := maxParams(d); max > 0 && len(b.Column) > 0 {
+		if limit := max / len(b.Column); limit < n {
+			n = limit
+		}
+	}
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// chunkLen returns the number of leading rows of value
+// that Build writes into one statement.
+// A chunk ends early at a row that omits other columns than the first row.
+func (b *InsertStmt) chunkLen(d Dialect, value [][]interface{}) int {
+	n := b.runLen(d)
+	if n > len(value) {
+		n = len(value)
+	}
+	for i := 1; i < n; i++ {
+		if !sameOmit(value[0], value[i]) {
+			return i
+		}
+	}
+	return n
+}
+
+type omitColumn struct{}
+
+// OmitColumn can be used as a value in Map, Values, or Pair
+// to leave the column out of the insert, so its default applies.
+// It is different from nil, which inserts NULL.
+//
+// Rows that omit different columns are inserted with separate statements.
+var OmitColumn interface{} = omitColumn{}
+
+// generatedColumn is the value Record adds for a field tagged generated.
+// It is left out like OmitColumn, and the value generated by the database
+// is scanned into ptr with RETURNING on postgres.
+type generatedColumn struct {
+	ptr interface{}
+}
+
+// omitKind returns 0 for a value to be inserted,
+// 1 for OmitColumn, and 2 for a generated column.
+func omitKind(v interface{}) int {
+	if v == OmitColumn {
+		return 1
+	}
+	if _, ok := v.(generatedColumn); ok {
+		return 2
+	}
+	return 0
+}
+
+// omitMask returns which values of tuple are OmitColumn or generated,
+// or nil if none is.
+func omitMask(tuple []interface{}) []bool {
+	var mask []bool
+	for i, v := range tuple {
+		if omitKind(v) == 0 {
+			continue
+		}
+		if mask == nil {
+			mask = make([]bool, len(tuple))
+		}
+		mask[i] = true
+	}
+	return mask
+}
+
+func sameOmit(a, b []interface{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if omitKind(a[i]) != omitKind(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func dropOmitted(tuple []interface{}) []interface{} {
+	kept := make([]interface{}, 0, len(tuple))
+	for _, v := range tuple {
+		if omitKind(v) == 0 {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+// groupByOmit moves rows that omit the same columns next to each other,
+// so they are inserted together. The order of rows within a group is kept.
+func groupByOmit(value [][]interface{}) [][]interface{} {
+	var group [][][]interface{}
+	for _, tuple := range value {
+		found := false
+		for i, g := range group {
+			if sameOmit(g[0], tuple) {
+				group[i] = append(g, tuple)
+				found = true
+				break
+			}
+		}
+		if !found {
+			group = append(group, [][]interface{}{tuple})
+		}
+	}
+	if len(group) <= 1 {
+		return value
+	}
+	sorted := make([][]interface{}, 0, len(value))
+	for _, g := range group {
+		sorted = append(sorted, g...)
+	}
+	return sorted
+}
+
+func hasBuilder(tuple []interface{}) bool {
+	for _, v := range tuple {
+		if _, ok := v.(Builder); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// buildTuple writes tuple to sql, and appends its values to value.
+// A value that is a Builder like Expr("NOW()") is written inline
+// with its own values, while others are bound to placeholders.
+func buildTuple(d Dialect, sql *strings.Builder, value []interface{}, tuple []interface{}) ([]interface{}, error) {
+	sql.WriteString("(")
+	for i, v := range tuple {
+		if i > 0 {
+			sql.WriteString(",")
+		}
+		builder, ok := v.(Builder)
+		if !ok {
+			sql.WriteString(placeholder)
+			value = append(value, v)
+			continue
+		}
+		pbuf := NewBuffer()
+		if err := builder.Build(d, pbuf); err != nil {
+			return value, err
+		}
+		switch builder.(type) {
+		case *SelectStmt, *union:
+			sql.WriteString("(")
+			sql.WriteString(pbuf.String())
+			sql.WriteString(")")
+		default:
+			sql.WriteString(pbuf.String())
+		}
+		value = append(value, pbuf.Value()...)
+	}
+	sql.WriteString(")")
+	return value, nil
+}
+
+// checkValues returns ErrValueCount naming the first row of value
+// that does not have a value for each column.
+func (b *InsertStmt) checkValues(value [][]interface{}) error {
+	for i, tuple := range value {
+		if len(tuple) != len(b.Column) {
+			return fmt.Errorf("%w: row %d has %d values for %d columns", ErrValueCount, i, len(tuple), len(b.Column))
+		}
+	}
+	return nil
+}
+
 // InsertInto creates an InsertStmt.
 func InsertInto(table string) *InsertStmt {
 	return &InsertStmt{
@@ -100,6 +482,10 @@ func (sess *Session) InsertInto(table string) *InsertStmt {
 	b.runner = sess
 	b.EventReceiver = sess.EventReceiver
 	b.Dialect = sess.Dialect
+	b.Partition = sess.partition
+	b.RunLen = sess.insertBatchSize
+	b.quietChunks = sess.quietChunks
+	b.TableMapper = sess.tableMapper
 	return b
 }
 
@@ -109,6 +495,10 @@ func (tx *Tx) InsertInto(table string) *InsertStmt {
 	b.runner = tx
 	b.EventReceiver = tx.EventReceiver
 	b.Dialect = tx.Dialect
+	b.Partition = tx.partition
+	b.RunLen = tx.insertBatchSize
+	b.quietChunks = tx.quietChunks
+	b.TableMapper = tx.tableMapper
 	return b
 }
 
@@ -147,6 +537,9 @@ func (b *InsertStmt) Columns(column ...string) *InsertStmt {
 
 // Values adds a tuple to be inserted.
 // The order of the tuple should match Columns.
+// A value can be a Builder, e.g. Expr("NOW()"), to insert an SQL expression.
+// A nil value inserts NULL, and OmitColumn leaves the column out.
+// Build and Exec return ErrValueCount if its length does not.
 func (b *InsertStmt) Values(value ...interface{}) *InsertStmt {
 	b.Value = append(b.Value, value)
 	return b
@@ -155,29 +548,58 @@ func (b *InsertStmt) Values(value ...interface{}) *InsertStmt {
 // Record adds a tuple for columns from a struct.
 //
 // If there is a field called "Id" or "ID" in the struct,
-// it will be set to LastInsertId.
+// it will be set to LastInsertId. Use IDColumn for another column.
+//
+// A nil pointer field inserts NULL. Use Values or Map with OmitColumn
+// to leave a column out so its default applies.
+//
+// A zero field is left out like OmitColumn if it is tagged
+// `db:"name,omitempty"`, or if OmitZero is set before Record.
+// Rows that leave out different columns are inserted with separate statements.
+//
+// A time.Time field with a `dblayout` tag is written as a string
+// formatted with the layout.
+//
+// A field tagged `db:"name,generated"`, e.g. a postgres
+// `GENERATED ALWAYS AS IDENTITY` column, is always left out.
+// On postgres, Exec sets it from RETURNING if structValue is a pointer
+// and Returning and OnConflict are not set.
 func (b *InsertStmt) Record(structValue interface{}) *InsertStmt {
 	v := reflect.Indirect(reflect.ValueOf(structValue))
 
 	if v.Kind() == reflect.Struct {
 		found := make([]interface{}, len(b.Column)+1)
 		// ID is recommended by golint here
+		idColumn := "id"
+		if b.idColumn != "" {
+			idColumn = b.idColumn
+		}
 		s := newTagStore()
-		s.findValueByName(v, append(b.Column, "id"), found, false)
+		s.findValueByName(v, append(b.Column, idColumn), found, false)
 
 		value := found[:len(found)-1]
 		for i, v := range value {
-			if v != nil {
-				value[i] = v.(reflect.Value).Interface()
+			switch v := v.(type) {
+			case generatedField:
+				var ptr interface{}
+				if v.CanAddr() {
+					ptr = v.Addr().Interface()
+				}
+				value[i] = generatedColumn{ptr}
+			case emptyField:
+				value[i] = OmitColumn
+			case reflect.Value:
+				if b.omitZero && v.IsZero() {
+					value[i] = OmitColumn
+				} else {
+					value[i] = v.Interface()
+				}
 			}
 		}
 
 		if v.CanSet() {
-			switch idField := found[len(found)-1].(type) {
-			case reflect.Value:
-				if idField.Kind() == reflect.Int64 {
-					b.RecordID = idField.Addr().Interface().(*int64)
-				}
+			if idField, ok := fieldValue(found[len(found)-1]); ok && idField.Kind() == reflect.Int64 {
+				b.RecordID = idField.Addr().Interface().(*int64)
 			}
 		}
 		b.Values(value...)
@@ -185,24 +607,78 @@ func (b *InsertStmt) Record(structValue interface{}) *InsertStmt {
 	return b
 }
 
-//插入map，key为column，value为value
+// IDColumn sets the column of the struct field that Record sets
+// to LastInsertId, e.g. "user_id", instead of "id".
+// It must be called before Record.
+func (b *InsertStmt) IDColumn(name string) *InsertStmt {
+	b.idColumn = name
+	return b
+}
+
+// 插入map，key为column，value为value
+// A nil value inserts NULL.
+//
+// If Columns is not set, the columns are taken from kv in sorted order,
+// leaving out those set to OmitColumn. A key that is new in a later map
+// adds a column, and a row without it leaves it out like OmitColumn,
+// so rows with different keys are inserted with separate statements.
+//
+// Otherwise a column missing from kv inserts NULL, and a key that is not
+// in Columns makes Build return an error wrapping ErrColumnNotSpecified.
 func (b *InsertStmt) Map(kv map[string]interface{}) *InsertStmt {
-	value := []interface{}{}
+	key := make([]string, 0, len(kv))
+	for k := range kv {
+		key = append(key, k)
+	}
+	sort.Strings(key)
+
 	if len(b.Column) == 0 {
-		for k, v := range kv {
-			b.Column = append(b.Column, k)
-			value = append(value, v)
+		b.mapColumns = true
+	}
+	if !b.mapColumns {
+		for _, k := range key {
+			if kv[k] != OmitColumn && !hasColumn(b.Column, k) && b.err == nil {
+				b.err = fmt.Errorf("%w: row %d of Map has %q, which is not in Columns", ErrColumnNotSpecified, len(b.Value), k)
+			}
 		}
-	} else {
-		for _, col := range b.Column {
-			v := kv[col]
-			value = append(value, v)
+		value := make([]interface{}, len(b.Column))
+		for i, col := range b.Column {
+			value[i] = kv[col]
+		}
+		b.Value = append(b.Value, value)
+		return b
+	}
+
+	for _, k := range key {
+		if kv[k] == OmitColumn || hasColumn(b.Column, k) {
+			continue
+		}
+		b.Column = append(b.Column, k)
+		for i := range b.Value {
+			b.Value[i] = append(b.Value[i], OmitColumn)
+		}
+	}
+	value := make([]interface{}, len(b.Column))
+	for i, col := range b.Column {
+		v, ok := kv[col]
+		if !ok {
+			v = OmitColumn
 		}
+		value[i] = v
 	}
 	b.Value = append(b.Value, value)
 	return b
 }
 
+func hasColumn(column []string, name string) bool {
+	for _, col := range column {
+		if col == name {
+			return true
+		}
+	}
+	return false
+}
+
 // Returning specifies the returning columns for postgres.
 func (b *InsertStmt) Returning(column ...string) *InsertStmt {
 	b.ReturnColumn = column
@@ -211,6 +687,8 @@ func (b *InsertStmt) Returning(column ...string) *InsertStmt {
 
 // Pair adds (column, value) to be inserted.
 // It is an error to mix Pair with Values and Record.
+// If more than one record was added, ErrPairMultipleRows is
+// returned when the statement is built.
 func (b *InsertStmt) Pair(column string, value interface{}) *InsertStmt {
 	b.Column = append(b.Column, column)
 	switch len(b.Value) {
@@ -219,34 +697,281 @@ func (b *InsertStmt) Pair(column string, value interface{}) *InsertStmt {
 	case 1:
 		b.Value[0] = append(b.Value[0], value)
 	default:
-		panic("pair only allows one record to insert")
+		b.err = ErrPairMultipleRows
 	}
 	return b
 }
 
+// OnDupKeyUpdate sets column in `ON DUPLICATE KEY UPDATE` on MySQL.
+// value can be a Builder, e.g. to only update to a larger value:
+//
+//	OnDupKeyUpdate("value", dbr.Expr("IF(? > value, ?, value)", dbr.ValuesOf("value"), dbr.ValuesOf("value")))
+//
+// Build returns ErrNotSupported for other dialects, and with Returning,
+// as MySQL cannot return the row. Use OnConflict on PostgreSQL and SQLite.
+func (b *InsertStmt) OnDupKeyUpdate(column string, value interface{}) *InsertStmt {
+	b.onDup = append(b.onDup, dupUpdate{column: column, value: value})
+	return b
+}
+
+// OnConflict writes `ON CONFLICT (target) DO NOTHING` on PostgreSQL and SQLite,
+// or `DO UPDATE SET ...` with the columns set by DoUpdate.
+// target is the columns of a unique index, and can be left out for DO NOTHING,
+// while Build returns ErrColumnNotSpecified without it for DO UPDATE.
+// Build returns ErrNotSupported on MySQL, where OnDupKeyUpdate should be used.
+//
+// With Returning, the inserted or updated row is returned,
+// e.g. to get or create a row atomically:
+//
+//	sess.InsertInto("tag").Columns("name").Values(name).
+//		OnConflict("name").DoUpdate("name", dbr.ValuesOf("name")).
+//		Returning("id", "name").LoadOne(&tag)
+//
+// Note that no row is returned for a conflict with DO NOTHING.
+func (b *InsertStmt) OnConflict(target ...string) *InsertStmt {
+	b.onConflict = true
+	b.conflict = target
+	return b
+}
+
+// DoUpdate sets column in `ON CONFLICT ... DO UPDATE SET` of OnConflict.
+// value can be a Builder like ValuesOf(column).
+func (b *InsertStmt) DoUpdate(column string, value interface{}) *InsertStmt {
+	b.onDup = append(b.onDup, dupUpdate{column: column, value: value})
+	return b
+}
+
+// ValuesOf refers to the value that would have been inserted into column,
+// i.e. `EXCLUDED.column` in `ON CONFLICT ... DO UPDATE` on PostgreSQL
+// and SQLite, and `VALUES(column)` in `ON DUPLICATE KEY UPDATE` otherwise.
+func ValuesOf(column string) Builder {
+	return BuildFunc(func(d Dialect, buf Buffer) error {
+		if isDialect(d, dialect.PostgreSQL) || isDialect(d, dialect.SQLite3) {
+			buf.WriteString("EXCLUDED.")
+			buf.WriteString(d.QuoteIdent(column))
+			return nil
+		}
+		buf.WriteString("VALUES(")
+		buf.WriteString(d.QuoteIdent(column))
+		buf.WriteString(")")
+		return nil
+	})
+}
+
+// OmitZero sets Record to leave out zero fields, so their defaults apply,
+// like fields tagged `db:"name,omitempty"`.
+// A nil pointer field is left out instead of inserting NULL.
+func (b *InsertStmt) OmitZero() *InsertStmt {
+	b.omitZero = true
+	return b
+}
+
+// With adds a common table expression to be referred to by name,
+// e.g. in a subquery of Values: `WITH name AS (...) INSERT INTO ...`.
+// Multiple expressions are joined with commas.
+// As an expression would run again for each chunk, e.g. one that
+// modifies rows, the rows must fit in one statement: Build and Exec
+// return ErrNotSupported if more than one is needed.
+// MySQL does not support WITH before INSERT, so Build returns ErrNotSupported.
+func (b *InsertStmt) With(name string, builder Builder) *InsertStmt {
+	b.with.add(name, builder, false)
+	return b
+}
+
+// WithRecursive is like With, but writes `WITH RECURSIVE`.
+func (b *InsertStmt) WithRecursive(name string, builder Builder) *InsertStmt {
+	b.with.add(name, builder, true)
+	return b
+}
+
+var errWithChunks = fmt.Errorf("%w: WITH for more than one chunk, raise RunLen or insert the rows separately", ErrNotSupported)
+
+// checkWith returns errWithChunks if the statement has a common table
+// expression, and ExecContext would run more than one statement.
+func (b *InsertStmt) checkWith() error {
+	if len(b.with.cte) > 0 && b.EstimateBatches() > 1 {
+		return errWithChunks
+	}
+	return nil
+}
+
+// Replace inserts the rows with `REPLACE INTO` on MySQL,
+// or `INSERT OR REPLACE INTO` on SQLite, which deletes a row
+// with the same key before inserting the new one.
+// Build returns ErrNotSupported for other dialects like PostgreSQL,
+// where `ON CONFLICT` should be used instead.
+func (b *InsertStmt) Replace() *InsertStmt {
+	b.replace = true
+	return b
+}
+
+// Reset clears the columns, values, and options of the statement
+// so it can be reused, e.g. from a sync.Pool, keeping its table,
+// runner, Dialect, and EventReceiver. RunLen is reset to the default
+// of the session. The session itself is not reset.
+func (b *InsertStmt) Reset() *InsertStmt {
+	b.raw = raw{}
+	// the slices may be the caller's, so they are not reused
+	b.Column = nil
+	b.Value = nil
+	b.RunLen = runnerBatchSize(b.runner)
+	b.ReturnColumn = nil
+	b.RecordID = nil
+	b.QueryLabel = ""
+	b.onBatch = nil
+	b.replace = false
+	b.omitZero = false
+	b.onDup = nil
+	b.onConflict = false
+	b.idColumn = ""
+	b.mapColumns = false
+	b.conflict = nil
+	b.returnInto = nil
+	b.with = withClause{}
+	b.err = nil
+	return b
+}
+
+// runnerBatchSize returns the insert batch size set on the session or Tx.
+func runnerBatchSize(runner runner) int {
+	switch r := runner.(type) {
+	case *Session:
+		return r.insertBatchSize
+	case *Tx:
+		return r.insertBatchSize
+	}
+	return 0
+}
+
+// Label names the statement. See SelectStmt.Label.
+func (b *InsertStmt) Label(name string) *InsertStmt {
+	b.QueryLabel = name
+	return b
+}
+
 //获取SQL
-func (b *InsertStmt) GetSQL() (string, error) {
-	b1 := *b
-	b2 := &b1
-	return getSQL(b2, b2.Dialect)
+// An optional dialect like NoQuote(nil) overrides the dialect of the statement.
+// A statement created without a session is written with MySQL by default.
+func (b *InsertStmt) GetSQL(d ...Dialect) (string, error) {
+	b2 := b.snapshot()
+	return getSQL(b2, previewDialect(b2.Dialect, d))
+}
+
+// Prepare builds the first chunk of the statement without executing it,
+// and returns the SQL with placeholders of its dialect and the values
+// bound to them, e.g. to validate it or run it with another *sql.DB.
+// Like GetSQL, it leaves the rows of the statement in place.
+func (b *InsertStmt) Prepare() (string, []interface{}, error) {
+	b2 := b.snapshot()
+	return prepareQuery(b2, previewDialect(b2.Dialect, nil))
+}
+
+// snapshot returns a copy of the statement to be built or executed
+// without consuming its rows, which are grouped like ExecContext does,
+// so the copy builds the first statement ExecContext would run.
+func (b *InsertStmt) snapshot() *InsertStmt {
+	b.guard.enter("InsertStmt")
+	defer b.guard.leave()
+	s := *b
+	s.guard = guard{}
+	s.Value = groupByOmit(s.Value)
+	return &s
 }
 
 func (b *InsertStmt) Exec() (sql.Result, error) {
 	return b.ExecContext(context.Background())
 }
-// Returning specifies the returning columns for postgres.
+
+// SetRunLen sets the number of rows inserted per statement.
+// It overrides the session default set by SetInsertBatchSize.
 func (b *InsertStmt) SetRunLen(i int) *InsertStmt {
 	//b.runnum
 	b.RunLen = i
 	return b
 }
 
+// ExecContext inserts the rows chunk by chunk.
+// As before, a statement created with InsertBySql is not executed,
+// and nil is returned; use LoadContext to run it.
+// A "dbr.insert.batch" timing event with the total rows and chunks
+// is sent after the last chunk, in addition to the event of each chunk
+// unless SuppressChunkEvents is set.
 func (b *InsertStmt) ExecContext(ctx context.Context) (sql.Result, error) {
-	var err error
+	var progress batchProgress
+	startTime := time.Now()
+	result, err := b.execContext(ctx, &progress)
+	if err != nil {
+		return nil, err
+	}
+	b.batchEvent(ctx, startTime, &progress)
+	return result, nil
+}
+
+// execContext is ExecContext without the "dbr.insert.batch" event,
+// counting the statements executed in progress.
+func (b *InsertStmt) execContext(ctx context.Context, progress *batchProgress) (sql.Result, error) {
+	b.guard.enter("InsertStmt")
+	defer b.guard.leave()
+	if b.err != nil {
+		return nil, b.err
+	}
+	if b.raw.Query != "" {
+		return nil, nil
+	}
+	// check every row before the first chunk is sent,
+	// so a bad row does not leave the insert half done.
+	if err := b.checkValues(b.Value); err != nil {
+		return nil, err
+	}
+	if err := b.checkWith(); err != nil {
+		return nil, err
+	}
+
+	if b.Partition != nil {
+		return b.execPartitioned(ctx, progress)
+	}
+	return b.execChunks(ctx, progress)
+}
+
+// batchEvent sends the "dbr.insert.batch" timing event
+// of the chunks counted in progress since startTime.
+// No event is sent for InsertBySql, which is not executed.
+func (b *InsertStmt) batchEvent(ctx context.Context, startTime time.Time, progress *batchProgress) {
+	if b.raw.Query != "" || b.EventReceiver == nil || !timingEnabled(b.EventReceiver) {
+		return
+	}
+	b.EventReceiver.TimingKv("dbr.insert.batch", time.Since(startTime).Nanoseconds(), eventKvs(ctx, b, kvs{
+		"rows":   strconv.Itoa(progress.rows),
+		"chunks": strconv.Itoa(progress.chunk),
+	}))
+}
+
+// batchProgress counts the chunks and rows inserted by ExecContext,
+// and the rows affected by them.
+type batchProgress struct {
+	chunk    int
+	rows     int
+	affected int64
+}
+
+// execChunks executes the rows chunk by chunk, and counts them in progress.
+func (b *InsertStmt) execChunks(ctx context.Context, progress *batchProgress) (sql.Result, error) {
+	log := b.EventReceiver
+	if b.quietChunks {
+		log = timingMuted{log}
+	}
+	b.Value = groupByOmit(b.Value)
 	var result sql.Result
-	for len(b.Value) > 0 && err == nil {
-		//_, err = b.ExecContext(context.Background())
-		result, err = exec(ctx, b.runner, b.EventReceiver, b, b.Dialect)
+	for len(b.Value) > 0 {
+		n := b.chunkLen(b.Dialect, b.Value)
+		var err error
+		if b.capturesGenerated() {
+			var count int
+			count, err = query(ctx, b.runner, log, b, b.Dialect, &b.returnInto)
+			result = rowsResult(count)
+		} else {
+			result, err = exec(ctx, b.runner, log, b, b.Dialect)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -256,15 +981,238 @@ func (b *InsertStmt) ExecContext(ctx context.Context) (sql.Result, error) {
 			}
 			b.RecordID = nil
 		}
+		progress.rows += n
+		if affected, err := result.RowsAffected(); err == nil {
+			progress.affected += affected
+		}
+		if b.onBatch != nil {
+			b.onBatch(progress.chunk, progress.rows)
+		}
+		progress.chunk++
+	}
+	return result, nil
+}
+
+// SuppressChunkEvents sets whether the timing event of each chunk is left out,
+// so only the "dbr.insert.batch" event of the whole insert is sent.
+// Error events are always sent.
+// It overrides the session default set by Session.SuppressChunkEvents.
+func (b *InsertStmt) SuppressChunkEvents(suppress bool) *InsertStmt {
+	b.quietChunks = suppress
+	return b
+}
+
+// ValuesFrom is like ValuesFromContext with the background context.
+func (b *InsertStmt) ValuesFrom(next func() ([]interface{}, bool)) (int64, error) {
+	return b.ValuesFromContext(context.Background(), next)
+}
+
+// ValuesFromContext inserts the tuples returned by next until it returns false,
+// e.g. to stream rows from a file. A chunk is executed whenever RunLen tuples
+// are collected, so no more than one chunk is held in memory.
+// Rows already added with Values or Record are inserted with the first chunk.
+// ctx is checked before each chunk.
+// OnBatch counts chunks and rows across the whole stream, and one
+// "dbr.insert.batch" event is sent after the last chunk, like ExecContext.
+// It returns the number of rows affected by the chunks executed so far,
+// also if a chunk fails.
+func (b *InsertStmt) ValuesFromContext(ctx context.Context, next func() ([]interface{}, bool)) (int64, error) {
+	size := b.runLen(b.Dialect)
+	chunk := b.Value
+	var progress batchProgress
+	startTime := time.Now()
+	for {
+		more := true
+		for more && len(chunk) < size {
+			var tuple []interface{}
+			tuple, more = next()
+			if more {
+				chunk = append(chunk, tuple)
+			}
+		}
+		if len(chunk) > 0 {
+			if err := ctx.Err(); err != nil {
+				return progress.affected, err
+			}
+			b.Value = chunk
+			if _, err := b.execContext(ctx, &progress); err != nil {
+				return progress.affected, err
+			}
+			chunk = chunk[:0]
+		}
+		if !more {
+			b.batchEvent(ctx, startTime, &progress)
+			return progress.affected, nil
+		}
+	}
+}
+
+// ExecR executes the statement like ExecContext,
+// and wraps its result in an ExecResult.
+func (b *InsertStmt) ExecR(ctx context.Context) (*ExecResult, error) {
+	result, err := b.ExecContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newExecResult(b.runner, result), nil
+}
+
+// OnBatch sets fn to be called after each statement of ExecContext,
+// with the index of the chunk and the number of rows inserted so far.
+// With Partition, chunks and rows are counted across all tables.
+// It is not called for InsertBySql.
+func (b *InsertStmt) OnBatch(fn func(chunk, rowsSoFar int)) *InsertStmt {
+	b.onBatch = fn
+	return b
+}
+
+// execPartitioned groups the rows by the table returned from Partition,
+// and inserts each group into its own table in the order they first appear.
+func (b *InsertStmt) execPartitioned(ctx context.Context, progress *batchProgress) (sql.Result, error) {
+	tables, group := b.partitionRows()
+	b.Value = nil
+
+	var result sql.Result
+	for _, table := range tables {
+		p := *b
+		b.RecordID = nil // only the first group sets the id
+		p.Table = table
+		p.Value = group[table]
+		var err error
+		result, err = p.execChunks(ctx, progress)
+		if err != nil {
+			return nil, err
+		}
 	}
 	return result, nil
 }
 
+// partitionRows groups the rows by the table returned from Partition.
+// The tables are returned in the order they first appear.
+func (b *InsertStmt) partitionRows() ([]string, map[string][][]interface{}) {
+	var tables []string
+	group := make(map[string][][]interface{})
+	for _, tuple := range b.Value {
+		row := make(map[string]interface{}, len(b.Column))
+		for i, col := range b.Column {
+			if i < len(tuple) {
+				row[col] = tuple[i]
+			}
+		}
+		table := b.Partition(b.Table, row)
+		if _, ok := group[table]; !ok {
+			tables = append(tables, table)
+		}
+		group[table] = append(group[table], tuple)
+	}
+	return tables, group
+}
+
+// EstimateBatches returns the number of statements ExecContext would run
+// for the rows added so far, without building or executing them.
+func (b *InsertStmt) EstimateBatches() int {
+	if b.raw.Query != "" {
+		return 1
+	}
+	if b.Partition == nil {
+		return b.countChunks(b.Value)
+	}
+	tables, group := b.partitionRows()
+	n := 0
+	for _, table := range tables {
+		n += b.countChunks(group[table])
+	}
+	return n
+}
+
+func (b *InsertStmt) countChunks(value [][]interface{}) int {
+	value = groupByOmit(value)
+	n := 0
+	for len(value) > 0 {
+		value = value[b.chunkLen(b.Dialect, value):]
+		n++
+	}
+	return n
+}
+
+// LoadContext executes the statement and loads the rows returned by RETURNING
+// into value, e.g. *int64 for a single id, []int64 for the ids of a batch,
+// or RowMap for a row by column name.
+// Every chunk is executed, into the tables of Partition if it is set,
+// and a slice collects the rows of all of them.
 func (b *InsertStmt) LoadContext(ctx context.Context, value interface{}) error {
-	_, err := query(ctx, b.runner, b.EventReceiver, b, b.Dialect, value)
+	_, err := b.load(ctx, value)
 	return err
 }
 
 func (b *InsertStmt) Load(value interface{}) error {
 	return b.LoadContext(context.Background(), value)
 }
+
+// LoadOneContext executes the statement and loads the single row returned
+// by RETURNING into value, which is not a slice.
+// It returns ErrNotFound if no row is returned, and ErrMultipleRows
+// if more than one is, in which case value holds the first row.
+func (b *InsertStmt) LoadOneContext(ctx context.Context, value interface{}) error {
+	count, err := b.load(ctx, oneLoader{value})
+	if err != nil {
+		return err
+	}
+	return checkOne(count)
+}
+
+// LoadOne is like LoadOneContext with the background context.
+func (b *InsertStmt) LoadOne(value interface{}) error {
+	return b.LoadOneContext(context.Background(), value)
+}
+
+// load executes every chunk, and returns the number of rows loaded.
+func (b *InsertStmt) load(ctx context.Context, value interface{}) (int, error) {
+	b.guard.enter("InsertStmt")
+	defer b.guard.leave()
+	if b.err != nil {
+		return 0, b.err
+	}
+	if b.raw.Query != "" {
+		return query(ctx, b.runner, b.EventReceiver, b, b.Dialect, value)
+	}
+	if err := b.checkValues(b.Value); err != nil {
+		return 0, err
+	}
+	if err := b.checkWith(); err != nil {
+		return 0, err
+	}
+	if b.Partition == nil {
+		return b.loadChunks(ctx, value)
+	}
+	// like execPartitioned
+	tables, group := b.partitionRows()
+	b.Value = nil
+	total := 0
+	for _, table := range tables {
+		p := *b
+		p.Table = table
+		p.Value = group[table]
+		count, err := p.loadChunks(ctx, value)
+		total += count
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// loadChunks executes the rows chunk by chunk,
+// and returns the number of rows loaded.
+func (b *InsertStmt) loadChunks(ctx context.Context, value interface{}) (int, error) {
+	b.Value = groupByOmit(b.Value)
+	total := 0
+	for len(b.Value) > 0 {
+		count, err := query(ctx, b.runner, b.EventReceiver, b, b.Dialect, value)
+		if err != nil {
+			return total, err
+		}
+		total += count
+	}
+	return total, nil
+}
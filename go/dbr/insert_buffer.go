@@ -0,0 +1,120 @@
+package dbr
+
+import (
+	"sync"
+	"time"
+)
+
+// InsertBuffer collects rows and inserts them in batches.
+// A batch is flushed when it reaches maxRows, or maxDelay after
+// its first row was added, whichever comes first.
+//
+// It is safe for concurrent use.
+type InsertBuffer struct {
+	sess     *Session
+	table    string
+	column   []string
+	maxRows  int
+	maxDelay time.Duration
+
+	mu     sync.Mutex
+	value  [][]interface{}
+	timer  *time.Timer
+	err    error
+	closed bool
+}
+
+// NewInsertBuffer creates an InsertBuffer for table and columns.
+// If maxDelay is 0, rows are only flushed when maxRows is reached,
+// or by Flush and Close.
+func (sess *Session) NewInsertBuffer(table string, column []string, maxRows int, maxDelay time.Duration) *InsertBuffer {
+	if maxRows <= 0 {
+		maxRows = defaultRunLen
+	}
+	return &InsertBuffer{
+		sess:     sess,
+		table:    table,
+		column:   column,
+		maxRows:  maxRows,
+		maxDelay: maxDelay,
+	}
+}
+
+// Add adds a row to the buffer.
+// The order of the values should match the columns of the buffer.
+//
+// If a background flush failed since the last call,
+// its error is returned and the row is not added.
+func (b *InsertBuffer) Add(value ...interface{}) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrBufferClosed
+	}
+	if err := b.takeErr(); err != nil {
+		return err
+	}
+	b.value = append(b.value, value)
+	if len(b.value) >= b.maxRows {
+		return b.flush()
+	}
+	if b.timer == nil && b.maxDelay > 0 {
+		b.timer = time.AfterFunc(b.maxDelay, b.flushTimer)
+	}
+	return nil
+}
+
+// Flush inserts the buffered rows now.
+func (b *InsertBuffer) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.takeErr(); err != nil {
+		return err
+	}
+	return b.flush()
+}
+
+// Close flushes the remaining rows and stops the buffer.
+// Add returns ErrBufferClosed after Close.
+func (b *InsertBuffer) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	if err := b.takeErr(); err != nil {
+		return err
+	}
+	return b.flush()
+}
+
+func (b *InsertBuffer) flushTimer() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.flush(); err != nil {
+		b.err = err
+	}
+}
+
+func (b *InsertBuffer) takeErr() error {
+	err := b.err
+	b.err = nil
+	return err
+}
+
+// flush must be called with b.mu held.
+func (b *InsertBuffer) flush() error {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	if len(b.value) == 0 {
+		return nil
+	}
+	stmt := b.sess.InsertInto(b.table).Columns(b.column...).SetRunLen(b.maxRows)
+	stmt.Value = b.value
+	b.value = nil
+	_, err := stmt.Exec()
+	return err
+}
@@ -0,0 +1,59 @@
+package dbr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gavin2014/lib/go/dbr/dialect"
+)
+
+func TestInsertBufferTimer(t *testing.T) {
+	sess, db := newTestSession(dialect.MySQL)
+	buf := sess.NewInsertBuffer("t", []string{"a"}, 100, 20*time.Millisecond)
+	if err := buf.Add(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := buf.Add(2); err != nil {
+		t.Fatal(err)
+	}
+	if q := db.Queries(); len(q) != 0 {
+		t.Fatalf("flushed before maxDelay: %q", q)
+	}
+	deadline := time.Now().Add(time.Second)
+	for len(db.Queries()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	checkQueries(t, db, "INSERT INTO `t` (`a`) VALUES (1), (2)")
+
+	if err := buf.Add(3); err != nil {
+		t.Fatal(err)
+	}
+	if err := buf.Close(); err != nil {
+		t.Fatal(err)
+	}
+	checkQueries(t, db,
+		"INSERT INTO `t` (`a`) VALUES (1), (2)",
+		"INSERT INTO `t` (`a`) VALUES (3)",
+	)
+	if err := buf.Add(4); err != ErrBufferClosed {
+		t.Fatalf("Add after Close = %v, want ErrBufferClosed", err)
+	}
+}
+
+func TestInsertBufferMaxRows(t *testing.T) {
+	sess, db := newTestSession(dialect.MySQL)
+	buf := sess.NewInsertBuffer("t", []string{"a"}, 2, 0)
+	for i := 1; i <= 3; i++ {
+		if err := buf.Add(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkQueries(t, db, "INSERT INTO `t` (`a`) VALUES (1), (2)")
+	if err := buf.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	checkQueries(t, db,
+		"INSERT INTO `t` (`a`) VALUES (1), (2)",
+		"INSERT INTO `t` (`a`) VALUES (3)",
+	)
+}
@@ -0,0 +1,748 @@
+package dbr
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gavin2014/lib/go/dbr/dialect"
+)
+
+func monthPartition(table string, row map[string]interface{}) string {
+	return fmt.Sprintf("%s_%v", table, row["month"])
+}
+
+func TestInsertPartition(t *testing.T) {
+	sess, db := newTestSession(dialect.MySQL)
+	sess.PartitionRouter(monthPartition)
+	_, err := sess.InsertInto("events").Columns("month", "n").
+		Values("2024_01", 1).
+		Values("2024_02", 2).
+		Values("2024_01", 3).
+		Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkQueries(t, db,
+		"INSERT INTO `events_2024_01` (`month`,`n`) VALUES ('2024_01',1), ('2024_01',3)",
+		"INSERT INTO `events_2024_02` (`month`,`n`) VALUES ('2024_02',2)",
+	)
+}
+
+func TestInsertPartitionRecordID(t *testing.T) {
+	type event struct {
+		ID    int64
+		Month string
+	}
+	sess, db := newTestSession(dialect.MySQL)
+	sess.PartitionRouter(monthPartition)
+	db.onExec = func(string) { db.lastID += 10 }
+	e := event{Month: "2024_01"}
+	_, err := sess.InsertInto("events").Columns("month").
+		Record(&e).
+		Values("2024_02").
+		Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(db.Queries()) != 2 {
+		t.Fatalf("queries = %q", db.Queries())
+	}
+	if e.ID != 10 {
+		t.Fatalf("ID = %d, want the id of the first group", e.ID)
+	}
+}
+
+func BenchmarkInsertPartition(b *testing.B) {
+	sess, _ := newTestSession(dialect.MySQL)
+	sess.PartitionRouter(monthPartition)
+	months := []string{"2024_01", "2024_02", "2024_03"}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		stmt := sess.InsertInto("events").Columns("month", "n")
+		for j := 0; j < 1000; j++ {
+			stmt.Values(months[j%len(months)], j)
+		}
+		if _, err := stmt.Exec(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkInsertBuild5000(b *testing.B) {
+	value := make([][]interface{}, 5000)
+	for i := range value {
+		value[i] = []interface{}{i, "name", 1.5}
+	}
+	stmt := InsertInto("t").Columns("a", "b", "c").SetRunLen(len(value))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		stmt.Value = value
+		if err := stmt.Build(dialect.MySQL, NewBuffer()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestInsertBuildValues(t *testing.T) {
+	stmt := InsertInto("t").Columns("a", "b").Values(1, "x").Values(2, "y")
+	buf := NewBuffer()
+	if err := stmt.Build(dialect.PostgreSQL, buf); err != nil {
+		t.Fatal(err)
+	}
+	if want := `INSERT INTO "t" ("a","b") VALUES (?,?), (?,?)`; buf.String() != want {
+		t.Fatalf("sql = %s, want %s", buf.String(), want)
+	}
+	if got, want := fmt.Sprint(buf.Value()), "[1 x 2 y]"; got != want {
+		t.Fatalf("values = %s, want %s", got, want)
+	}
+}
+
+func TestInsertEstimateBatches(t *testing.T) {
+	for _, test := range []struct {
+		d      Dialect
+		runLen int
+		rows   int
+		want   int
+	}{
+		{dialect.MySQL, 3, 10, 4},
+		{dialect.MySQL, 0, 2500, 3},
+		// 999 placeholders for 2 columns allow 499 rows per statement
+		{dialect.SQLite3, 0, 1000, 3},
+		{dialect.SQLite3, 0, 0, 0},
+	} {
+		stmt := InsertInto("t").Columns("a", "b").SetRunLen(test.runLen)
+		stmt.Dialect = test.d
+		for i := 0; i < test.rows; i++ {
+			stmt.Values(i, i)
+		}
+		if n := stmt.EstimateBatches(); n != test.want {
+			t.Errorf("%d rows, RunLen %d: EstimateBatches() = %d, want %d", test.rows, test.runLen, n, test.want)
+		}
+		if len(stmt.Value) != test.rows {
+			t.Errorf("EstimateBatches consumed the rows")
+		}
+	}
+}
+
+func TestInsertOnBatch(t *testing.T) {
+	sess, db := newTestSession(dialect.MySQL)
+	type batch struct{ chunk, rows int }
+	var got []batch
+	stmt := sess.InsertInto("t").Columns("a").SetRunLen(2).OnBatch(func(chunk, rows int) {
+		got = append(got, batch{chunk, rows})
+	})
+	for i := 0; i < 5; i++ {
+		stmt.Values(i)
+	}
+	if _, err := stmt.Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []batch{{0, 2}, {1, 4}, {2, 5}}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("OnBatch calls = %v, want %v", got, want)
+	}
+	if n := len(db.Queries()); n != 3 {
+		t.Fatalf("%d statements, want 3", n)
+	}
+}
+
+func TestInsertBySqlExec(t *testing.T) {
+	sess, db := newTestSession(dialect.MySQL)
+	called := false
+	result, err := sess.InsertBySql("INSERT INTO t VALUES (?)", 1).OnBatch(func(int, int) {
+		called = true
+	}).Exec()
+	if result != nil || err != nil {
+		t.Fatalf("Exec() = %v, %v", result, err)
+	}
+	if called {
+		t.Fatal("OnBatch called for InsertBySql")
+	}
+	checkQueries(t, db)
+}
+
+func TestInsertReplace(t *testing.T) {
+	for _, test := range []struct {
+		d     Dialect
+		query string
+	}{
+		{dialect.MySQL, "REPLACE INTO `t` (`a`,`b`) VALUES (?,?), (?,?)"},
+		{dialect.SQLite3, `INSERT OR REPLACE INTO "t" ("a","b") VALUES (?,?), (?,?)`},
+	} {
+		stmt := InsertInto("t").Columns("a", "b").Values(1, 2).Values(3, 4).Replace()
+		checkBuild(t, stmt, test.d, test.query, 1, 2, 3, 4)
+	}
+	stmt := InsertInto("t").Columns("a").Values(1).Replace()
+	if err := stmt.Build(dialect.PostgreSQL, NewBuffer()); !errors.Is(err, ErrNotSupported) {
+		t.Fatalf("Build on PostgreSQL = %v, want ErrNotSupported", err)
+	}
+}
+
+func TestInsertOnDupKeyUpdateIf(t *testing.T) {
+	sess, db := newTestSession(dialect.MySQL)
+	_, err := sess.InsertInto("t").Columns("id", "value").Values(1, 10).
+		OnDupKeyUpdate("value", Expr("IF(? > value, ?, value)", ValuesOf("value"), ValuesOf("value"))).
+		Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkQueries(t, db, "INSERT INTO `t` (`id`,`value`) VALUES (1,10) ON DUPLICATE KEY UPDATE `value` = IF(VALUES(`value`) > value, VALUES(`value`), value)")
+}
+
+func TestInsertResetPool(t *testing.T) {
+	sess, db := newTestSession(dialect.MySQL)
+	pool := sync.Pool{New: func() interface{} { return sess.InsertInto("t") }}
+
+	column := []string{"a", "b"}
+	stmt := pool.Get().(*InsertStmt)
+	if _, err := stmt.Columns(column...).Values(1, 2).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	pool.Put(stmt.Reset())
+
+	stmt = pool.Get().(*InsertStmt)
+	if _, err := stmt.Pair("x", 3).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(column) != "[a b]" {
+		t.Fatalf("Reset reused the columns passed to Columns: %v", column)
+	}
+	checkQueries(t, db,
+		"INSERT INTO `t` (`a`,`b`) VALUES (1,2)",
+		"INSERT INTO `t` (`x`) VALUES (3)",
+	)
+}
+
+type generatedRow struct {
+	ID   int64 `db:"id,generated"`
+	Name string
+}
+
+func TestInsertGenerated(t *testing.T) {
+	sess, db := newTestSession(dialect.PostgreSQL)
+	db.addRows([]string{"id"}, []driver.Value{int64(7)}, []driver.Value{int64(8)})
+	a, b := generatedRow{Name: "a"}, generatedRow{Name: "b"}
+	if _, err := sess.InsertInto("t").Columns("id", "name").Record(&a).Record(&b).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	checkQueries(t, db, `INSERT INTO "t" ("name") VALUES ('a'), ('b') RETURNING "id"`)
+	if a.ID != 7 || b.ID != 8 {
+		t.Fatalf("ids = %d, %d, want 7, 8", a.ID, b.ID)
+	}
+}
+
+func TestInsertGeneratedOnConflict(t *testing.T) {
+	sess, db := newTestSession(dialect.PostgreSQL)
+	// rows in conflict are not returned, so ids are not captured by position
+	a, b := generatedRow{Name: "a"}, generatedRow{Name: "b"}
+	_, err := sess.InsertInto("t").Columns("id", "name").Record(&a).Record(&b).
+		OnConflict("name").
+		Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkQueries(t, db, `INSERT INTO "t" ("name") VALUES ('a'), ('b') ON CONFLICT ("name") DO NOTHING`)
+	if a.ID != 0 || b.ID != 0 {
+		t.Fatalf("ids = %d, %d, want them left unset", a.ID, b.ID)
+	}
+}
+
+func TestInsertLoadOne(t *testing.T) {
+	sess, db := newTestSession(dialect.PostgreSQL)
+	db.addRows([]string{"id", "name"}, []driver.Value{int64(1), "a"})
+	var row struct {
+		ID   int64
+		Name string
+	}
+	err := sess.InsertInto("t").Columns("name").Values("a").Returning("id", "name").LoadOne(&row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.ID != 1 || row.Name != "a" {
+		t.Fatalf("loaded %+v", row)
+	}
+	checkQueries(t, db, `INSERT INTO "t" ("name") VALUES ('a') RETURNING "id","name"`)
+
+	// a conflict with DO NOTHING returns no row
+	err = sess.InsertInto("t").Columns("name").Values("a").OnConflict("name").Returning("id").LoadOne(&row.ID)
+	if err != ErrNotFound {
+		t.Fatalf("LoadOne() = %v, want ErrNotFound", err)
+	}
+}
+
+func TestInsertWith(t *testing.T) {
+	sess, db := newTestSession(dialect.PostgreSQL)
+	moved := Expr("DELETE FROM queue WHERE id < ? RETURNING id", 10)
+	_, err := sess.InsertInto("done").Columns("n").
+		With("moved", moved).
+		Values(1).
+		Values(2).
+		Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkQueries(t, db, `WITH "moved" AS (DELETE FROM queue WHERE id < 10 RETURNING id) INSERT INTO "done" ("n") VALUES (1), (2)`)
+
+	// the CTE would run once per chunk
+	for _, stmt := range []*InsertStmt{
+		sess.InsertInto("done").Columns("n").With("moved", moved).SetRunLen(1).Values(1).Values(2),
+		sess.InsertInto("done").Columns("n").With("moved", moved).Values(1).Values(OmitColumn),
+	} {
+		if _, err := stmt.Exec(); !errors.Is(err, ErrNotSupported) {
+			t.Errorf("Exec() = %v, want ErrNotSupported", err)
+		}
+	}
+	partitioned := sess.InsertInto("events").Columns("month").With("moved", moved).Values("2024_01").Values("2024_02")
+	partitioned.Partition = monthPartition
+	if _, err := partitioned.Exec(); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("Exec() with partitions = %v, want ErrNotSupported", err)
+	}
+	if n := len(db.Queries()); n != 1 {
+		t.Fatalf("%d statements, want only the first insert", n)
+	}
+}
+
+// customDialect is a third-party Dialect without MaxParams.
+type customDialect struct {
+	Dialect
+}
+
+func TestInsertWideRows(t *testing.T) {
+	column := make([]string, 100)
+	for i := range column {
+		column[i] = "c" + strconv.Itoa(i)
+	}
+	tuple := make([]interface{}, len(column))
+	for i := range tuple {
+		tuple[i] = i
+	}
+	for _, test := range []struct {
+		d    Dialect
+		rows []int
+	}{
+		// 65535 values allow 655 rows of 100 columns per statement
+		{dialect.PostgreSQL, []int{655, 345}},
+		{customDialect{dialect.PostgreSQL}, []int{1000}},
+	} {
+		sess, db := newTestSession(test.d)
+		stmt := sess.InsertInto("t").Columns(column...)
+		for i := 0; i < 1000; i++ {
+			stmt.Values(tuple...)
+		}
+		if _, err := stmt.Exec(); err != nil {
+			t.Fatal(err)
+		}
+		var rows []int
+		for _, query := range db.Queries() {
+			rows = append(rows, strings.Count(query, "), (")+1)
+		}
+		if fmt.Sprint(rows) != fmt.Sprint(test.rows) {
+			t.Errorf("%T: rows per statement = %v, want %v", test.d, rows, test.rows)
+		}
+	}
+}
+
+func TestInsertOnConflict(t *testing.T) {
+	for _, test := range []struct {
+		d     Dialect
+		query string
+	}{
+		{dialect.PostgreSQL, `INSERT INTO "tag" ("name") VALUES ('a') ON CONFLICT ("name") DO UPDATE SET "name" = EXCLUDED."name" RETURNING "id"`},
+		{dialect.SQLite3, `INSERT INTO "tag" ("name") VALUES ('a') ON CONFLICT ("name") DO UPDATE SET "name" = EXCLUDED."name" RETURNING "id"`},
+	} {
+		sess, db := newTestSession(test.d)
+		db.addRows([]string{"id"}, []driver.Value{int64(5)})
+		var id int64
+		err := sess.InsertInto("tag").Columns("name").Values("a").
+			OnConflict("name").DoUpdate("name", ValuesOf("name")).
+			Returning("id").
+			LoadOne(&id)
+		if err != nil || id != 5 {
+			t.Fatalf("LoadOne() = %d, %v", id, err)
+		}
+		checkQueries(t, db, test.query)
+	}
+
+	stmt := InsertInto("tag").Columns("name").Values("a").OnConflict().DoUpdate("name", ValuesOf("name"))
+	if err := stmt.Build(dialect.PostgreSQL, NewBuffer()); !errors.Is(err, ErrColumnNotSpecified) {
+		t.Fatalf("DoUpdate without a target: Build() = %v, want ErrColumnNotSpecified", err)
+	}
+	stmt = InsertInto("tag").Columns("name").Values("a").OnConflict("name")
+	if err := stmt.Build(dialect.MySQL, NewBuffer()); !errors.Is(err, ErrNotSupported) {
+		t.Fatalf("Build on MySQL = %v, want ErrNotSupported", err)
+	}
+}
+
+func TestValuesOf(t *testing.T) {
+	for _, test := range []struct {
+		d     Dialect
+		query string
+	}{
+		{dialect.MySQL, "VALUES(`a`)"},
+		{NoQuote(dialect.MySQL), "VALUES(a)"},
+		{customDialect{dialect.MySQL}, "VALUES(`a`)"},
+		{dialect.PostgreSQL, `EXCLUDED."a"`},
+		{dialect.SQLite3, `EXCLUDED."a"`},
+	} {
+		checkBuild(t, ValuesOf("a"), test.d, test.query)
+	}
+}
+
+func TestInsertIDColumn(t *testing.T) {
+	type user struct {
+		UserID int64
+		Name   string
+	}
+	sess, db := newTestSession(dialect.MySQL)
+	db.lastID = 9
+	u := user{Name: "a"}
+	if _, err := sess.InsertInto("users").Columns("name").IDColumn("user_id").Record(&u).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if u.UserID != 9 {
+		t.Fatalf("UserID = %d, want 9", u.UserID)
+	}
+	checkQueries(t, db, "INSERT INTO `users` (`name`) VALUES ('a')")
+
+	// a zero id tagged omitempty is set too
+	type item struct {
+		ID   int64 `db:"id,omitempty"`
+		Name string
+	}
+	sess, db = newTestSession(dialect.MySQL)
+	db.lastID = 7
+	it := item{Name: "b"}
+	if _, err := sess.InsertInto("items").Columns("id", "name").Record(&it).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if it.ID != 7 {
+		t.Fatalf("ID = %d, want 7", it.ID)
+	}
+	checkQueries(t, db, "INSERT INTO `items` (`name`) VALUES ('b')")
+}
+
+// tuples returns a next func of ValuesFrom yielding n tuples,
+// which calls fn before each one.
+func tuples(n int, fn func(i int)) func() ([]interface{}, bool) {
+	i := 0
+	return func() ([]interface{}, bool) {
+		if i == n {
+			return nil, false
+		}
+		fn(i)
+		i++
+		return []interface{}{i}, true
+	}
+}
+
+func TestInsertValuesFrom(t *testing.T) {
+	sess, db := newTestSession(dialect.MySQL)
+	// the 3 rows added first are inserted by 2 statements
+	db.affected = []int64{2, 1, 2, 1}
+	stmt := sess.InsertInto("t").Columns("a").SetRunLen(2).Values(0).Values(0).Values(0)
+	total, err := stmt.ValuesFrom(tuples(3, func(int) {}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 6 {
+		t.Fatalf("ValuesFrom() = %d, want 6", total)
+	}
+	checkQueries(t, db,
+		"INSERT INTO `t` (`a`) VALUES (0), (0)",
+		"INSERT INTO `t` (`a`) VALUES (0)",
+		"INSERT INTO `t` (`a`) VALUES (1), (2)",
+		"INSERT INTO `t` (`a`) VALUES (3)",
+	)
+}
+
+func TestInsertValuesFromOnBatch(t *testing.T) {
+	for _, quiet := range []bool{false, true} {
+		sess, _ := newTestSession(dialect.MySQL)
+		rec := &RecordingEventReceiver{}
+		sess.EventReceiver = rec
+		sess.SuppressChunkEvents(quiet)
+		type batch struct{ chunk, rows int }
+		var got []batch
+		stmt := sess.InsertInto("t").Columns("a").SetRunLen(2).OnBatch(func(chunk, rows int) {
+			got = append(got, batch{chunk, rows})
+		})
+		if _, err := stmt.ValuesFrom(tuples(5, func(int) {})); err != nil {
+			t.Fatal(err)
+		}
+		if want := []batch{{0, 2}, {1, 4}, {2, 5}}; fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Fatalf("OnBatch calls = %v, want %v", got, want)
+		}
+
+		var chunks, batches []map[string]string
+		for _, e := range rec.Events() {
+			switch e.Name {
+			case "dbr.exec":
+				chunks = append(chunks, e.Kvs)
+			case "dbr.insert.batch":
+				batches = append(batches, e.Kvs)
+			}
+		}
+		want := 3
+		if quiet {
+			want = 0
+		}
+		if n := len(chunks); n != want {
+			t.Errorf("quiet %v: %d chunk events, want %d", quiet, n, want)
+		}
+		if len(batches) != 1 || batches[0]["rows"] != "5" || batches[0]["chunks"] != "3" {
+			t.Errorf("quiet %v: batch events = %v, want one of 5 rows in 3 chunks", quiet, batches)
+		}
+	}
+}
+
+func TestInsertValuesFromCancel(t *testing.T) {
+	sess, db := newTestSession(dialect.MySQL)
+	db.affected = []int64{2}
+	ctx, cancel := context.WithCancel(context.Background())
+	stmt := sess.InsertInto("t").Columns("a").SetRunLen(2)
+	total, err := stmt.ValuesFromContext(ctx, tuples(5, func(i int) {
+		if i == 2 {
+			cancel()
+		}
+	}))
+	if err != context.Canceled || total != 2 {
+		t.Fatalf("ValuesFromContext() = %d, %v, want 2, context.Canceled", total, err)
+	}
+	checkQueries(t, db, "INSERT INTO `t` (`a`) VALUES (1), (2)")
+}
+
+func TestInsertMap(t *testing.T) {
+	sess, db := newTestSession(dialect.MySQL)
+	_, err := sess.InsertInto("t").
+		Map(map[string]interface{}{"b": 2, "a": 1}).
+		Map(map[string]interface{}{"a": 3}).
+		Map(map[string]interface{}{"a": 4, "b": 5}).
+		Map(map[string]interface{}{"a": 6, "c": 7}).
+		Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkQueries(t, db,
+		"INSERT INTO `t` (`a`,`b`) VALUES (1,2), (4,5)",
+		"INSERT INTO `t` (`a`) VALUES (3)",
+		"INSERT INTO `t` (`a`,`c`) VALUES (6,7)",
+	)
+
+	sess, db = newTestSession(dialect.MySQL)
+	_, err = sess.InsertInto("t").Columns("a", "b").Map(map[string]interface{}{"a": 1}).Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkQueries(t, db, "INSERT INTO `t` (`a`,`b`) VALUES (1,NULL)")
+
+	_, err = sess.InsertInto("t").Columns("a").Map(map[string]interface{}{"a": 1, "x": 2}).Exec()
+	if !errors.Is(err, ErrColumnNotSpecified) {
+		t.Fatalf("Map with a key not in Columns: Exec() = %v, want ErrColumnNotSpecified", err)
+	}
+}
+
+func TestInsertPrepare(t *testing.T) {
+	sess, db := newTestSession(dialect.PostgreSQL)
+	stmt := sess.InsertInto("t").Columns("a", "b").
+		Values(1, OmitColumn).
+		Values(2, 2).
+		Values(3, OmitColumn)
+	query, value, err := stmt.Prepare()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `INSERT INTO "t" ("a") VALUES ($1), ($2)`; query != want || fmt.Sprint(value) != "[1 3]" {
+		t.Fatalf("Prepare() = %s %v, want %s [1 3]", query, value, want)
+	}
+	if _, err := stmt.Exec(); err != nil {
+		t.Fatal(err)
+	}
+	// Prepare builds the first statement of Exec
+	if first := db.Queries()[0]; first != `INSERT INTO "t" ("a") VALUES (1), (3)` {
+		t.Fatalf("Exec() ran %s first", first)
+	}
+}
+
+func TestInsertPartitionLoad(t *testing.T) {
+	sess, db := newTestSession(dialect.PostgreSQL)
+	sess.PartitionRouter(monthPartition)
+	db.addRows([]string{"id"}, []driver.Value{int64(1)}, []driver.Value{int64(3)})
+	db.addRows([]string{"id"}, []driver.Value{int64(2)})
+	var id []int64
+	err := sess.InsertInto("events").Columns("month", "n").
+		Values("2024_01", 1).
+		Values("2024_02", 2).
+		Values("2024_01", 3).
+		Returning("id").
+		Load(&id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkQueries(t, db,
+		`INSERT INTO "events_2024_01" ("month","n") VALUES ('2024_01',1), ('2024_01',3) RETURNING "id"`,
+		`INSERT INTO "events_2024_02" ("month","n") VALUES ('2024_02',2) RETURNING "id"`,
+	)
+	if fmt.Sprint(id) != "[1 3 2]" {
+		t.Fatalf("loaded %v", id)
+	}
+
+	db.addRows([]string{"id"}, []driver.Value{int64(4)})
+	var one int64
+	err = sess.InsertInto("events").Columns("month", "n").Values("2024_03", 4).Returning("id").LoadOne(&one)
+	if err != nil || one != 4 {
+		t.Fatalf("LoadOne() = %d, %v", one, err)
+	}
+	if last := db.Queries()[2]; last != `INSERT INTO "events_2024_03" ("month","n") VALUES ('2024_03',4) RETURNING "id"` {
+		t.Fatalf("LoadOne ran %s", last)
+	}
+}
+
+func TestInsertBatchSize(t *testing.T) {
+	sess, db := newTestSession(dialect.MySQL)
+	sess.SetInsertBatchSize(2)
+	tx, err := sess.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []*InsertStmt{sess.InsertInto("t"), tx.InsertInto("t")} {
+		stmt.Columns("a")
+		for i := 0; i < 5; i++ {
+			stmt.Values(i)
+		}
+		if _, err := stmt.Exec(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Reset keeps the batch size of the session
+	stmt := sess.InsertInto("t").SetRunLen(10).Reset().Columns("a").Values(5).Values(6).Values(7)
+	if _, err := stmt.Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for _, query := range db.Queries() {
+		if strings.HasPrefix(query, "INSERT") {
+			n++
+		}
+	}
+	if n != 3+3+2 {
+		t.Fatalf("%d statements, want 8: %q", n, db.Queries())
+	}
+}
+
+func TestInsertValueCount(t *testing.T) {
+	for _, value := range [][]interface{}{{1}, {1, 2, 3}} {
+		stmt := InsertInto("t").Columns("a", "b").Values(value...)
+		if err := stmt.Build(dialect.MySQL, NewBuffer()); !errors.Is(err, ErrValueCount) {
+			t.Errorf("%d values: Build() = %v, want ErrValueCount", len(value), err)
+		}
+
+		sess, db := newTestSession(dialect.MySQL)
+		_, err := sess.InsertInto("t").Columns("a", "b").Values(1, 2).Values(value...).Exec()
+		if !errors.Is(err, ErrValueCount) {
+			t.Errorf("%d values: Exec() = %v, want ErrValueCount", len(value), err)
+		}
+		checkQueries(t, db)
+	}
+}
+
+func TestInsertExprChunks(t *testing.T) {
+	insert := func(sess *Session) error {
+		_, err := sess.InsertInto("t").Columns("a", "b", "c").
+			Values(1, Expr("NOW()"), "x").
+			Values(2, Expr("? + ?", 10, 1), "y").
+			Values(3, Expr("LOWER(?)", "Z"), "z").
+			SetRunLen(2).
+			Exec()
+		return err
+	}
+
+	sess, db := newTestSession(dialect.MySQL)
+	if err := insert(sess); err != nil {
+		t.Fatal(err)
+	}
+	checkQueries(t, db,
+		"INSERT INTO `t` (`a`,`b`,`c`) VALUES (1,NOW(),'x'), (2,10 + 1,'y')",
+		"INSERT INTO `t` (`a`,`b`,`c`) VALUES (3,LOWER('Z'),'z')",
+	)
+
+	// the args of an expression are bound in place with placeholders
+	sess, db = newTestSession(dialect.PostgreSQL)
+	sess.CacheStatements(true)
+	if err := insert(sess); err != nil {
+		t.Fatal(err)
+	}
+	checkQueries(t, db,
+		`INSERT INTO "t" ("a","b","c") VALUES ($1,NOW(),$2), ($3,$4 + $5,$6)`,
+		`INSERT INTO "t" ("a","b","c") VALUES ($1,LOWER($2),$3)`,
+	)
+	want := [][]driver.Value{{int64(1), "x", int64(2), int64(10), int64(1), "y"}, {int64(3), "Z", "z"}}
+	if !reflect.DeepEqual(db.args, want) {
+		t.Fatalf("args = %v, want %v", db.args, want)
+	}
+}
+
+func TestInsertPairMultipleRows(t *testing.T) {
+	sess, db := newTestSession(dialect.MySQL)
+	stmt := sess.InsertInto("t").Columns("a").Values(1).Values(2).Pair("b", 3)
+	if err := stmt.Build(dialect.MySQL, NewBuffer()); !errors.Is(err, ErrPairMultipleRows) {
+		t.Fatalf("Build() = %v, want ErrPairMultipleRows", err)
+	}
+	if _, err := stmt.Exec(); !errors.Is(err, ErrPairMultipleRows) {
+		t.Fatalf("Exec() = %v, want ErrPairMultipleRows", err)
+	}
+	checkQueries(t, db)
+
+	// pairs of one row are fine
+	checkBuild(t, InsertInto("t").Pair("a", 1).Pair("b", 2), dialect.MySQL, "INSERT INTO `t` (`a`,`b`) VALUES (?,?)", 1, 2)
+}
+
+func TestInsertOmitEmpty(t *testing.T) {
+	type user struct {
+		ID    int64  `db:"id"`
+		Name  string `db:"name,omitempty"`
+		Email string `db:"email,omitempty"`
+	}
+	sess, db := newTestSession(dialect.MySQL)
+	_, err := sess.InsertInto("users").Columns("name", "email").
+		Record(&user{Name: "a", Email: "a@x"}).
+		Record(&user{Name: "b"}).
+		Record(&user{Name: "c"}).
+		Record(&user{Email: "d@x"}).
+		Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkQueries(t, db,
+		"INSERT INTO `users` (`name`,`email`) VALUES ('a','a@x')",
+		"INSERT INTO `users` (`name`) VALUES ('b'), ('c')",
+		"INSERT INTO `users` (`email`) VALUES ('d@x')",
+	)
+
+	type item struct {
+		Name  string `db:"name"`
+		Count int    `db:"count"`
+	}
+	sess, db = newTestSession(dialect.MySQL)
+	_, err = sess.InsertInto("items").Columns("name", "count").OmitZero().
+		Record(&item{Name: "a"}).
+		Record(&item{Count: 2}).
+		Record(&item{Name: "c", Count: 3}).
+		Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkQueries(t, db,
+		"INSERT INTO `items` (`name`) VALUES ('a')",
+		"INSERT INTO `items` (`count`) VALUES (2)",
+		"INSERT INTO `items` (`name`,`count`) VALUES ('c',3)",
+	)
+}
@@ -13,7 +13,15 @@ type interpolator struct {
 	Buffer
 	Dialect
 	IgnoreBinary bool
-	N            int
+	// Bind binds every value to a placeholder instead of
+	// interpolating it, except Builders which are written inline.
+	Bind bool
+	N    int
+
+	// query and args are the SQL of the top-level Builder with
+	// placeholders, and its values, before they are interpolated.
+	query string
+	args  []interface{}
 }
 
 // InterpolateForDialect replaces placeholder
@@ -44,6 +52,22 @@ func InterpolateForDialect(query string, value []interface{}, d Dialect) (string
 	return i.String(), nil
 }
 
+// EncodeValue encodes v as an SQL literal in dialect d, the same way
+// values are interpolated by GetSQL and InterpolateForDialect.
+// A slice is encoded as a parenthesized list like `('a','b''c')`,
+// and each element is encoded by its own type, e.g. strings are escaped.
+func EncodeValue(d Dialect, v interface{}) (string, error) {
+	i := interpolator{
+		Buffer:  NewBuffer(),
+		Dialect: d,
+	}
+	err := i.encodePlaceholder(v, true)
+	if err != nil {
+		return "", err
+	}
+	return i.String(), nil
+}
+
 var escapedPlaceholder = strings.Repeat(placeholder, 2)
 
 func (i *interpolator) interpolate(query string, value []interface{}, topLevel bool) error {
@@ -68,9 +92,14 @@ func (i *interpolator) interpolate(query string, value []interface{}, topLevel b
 
 		i.WriteString(query[:index])
 		if _, ok := value[valueIndex].([]byte); ok && i.IgnoreBinary {
-			i.WriteString(i.Placeholder(i.N))
-			i.N++
-			i.WriteValue(value[valueIndex])
+			i.bind(value[valueIndex])
+		} else if i.Bind && bindable(value[valueIndex]) {
+			i.bind(value[valueIndex])
+		} else if i.Bind && isList(value[valueIndex]) {
+			err := i.bindList(reflect.ValueOf(value[valueIndex]), topLevel)
+			if err != nil {
+				return err
+			}
 		} else {
 			err := i.encodePlaceholder(value[valueIndex], topLevel)
 			if err != nil {
@@ -95,6 +124,53 @@ var (
 	typeTime = reflect.TypeOf(time.Time{})
 )
 
+// bind writes a placeholder for v.
+func (i *interpolator) bind(v interface{}) {
+	i.WriteString(i.Placeholder(i.N))
+	i.N++
+	i.WriteValue(v)
+}
+
+// bindList writes a placeholder for each element of the slice v, like `(?,?)`.
+func (i *interpolator) bindList(v reflect.Value, topLevel bool) error {
+	if v.Len() == 0 {
+		return ErrInvalidSliceLength
+	}
+	i.WriteString("(")
+	for n := 0; n < v.Len(); n++ {
+		if n > 0 {
+			i.WriteString(",")
+		}
+		elem := v.Index(n).Interface()
+		if !bindable(elem) {
+			if err := i.encodePlaceholder(elem, topLevel); err != nil {
+				return err
+			}
+			continue
+		}
+		i.bind(elem)
+	}
+	i.WriteString(")")
+	return nil
+}
+
+// bindable reports whether v can be bound to a placeholder as is.
+func bindable(v interface{}) bool {
+	if _, ok := v.(Builder); ok {
+		return false
+	}
+	if _, ok := v.(driver.Valuer); ok {
+		return true
+	}
+	return !isList(v)
+}
+
+// isList reports whether v is a slice to be written as a list, like `(1,2)`.
+func isList(v interface{}) bool {
+	t := reflect.TypeOf(v)
+	return t != nil && t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8
+}
+
 func (i *interpolator) encodePlaceholder(value interface{}, topLevel bool) error {
 	if builder, ok := value.(Builder); ok {
 		pbuf := NewBuffer()
@@ -102,6 +178,9 @@ func (i *interpolator) encodePlaceholder(value interface{}, topLevel bool) error
 		if err != nil {
 			return err
 		}
+		if topLevel {
+			i.query, i.args = pbuf.String(), pbuf.Value()
+		}
 		paren := false
 		switch value.(type) {
 		case *SelectStmt, *union:
@@ -120,6 +199,11 @@ func (i *interpolator) encodePlaceholder(value interface{}, topLevel bool) error
 		return nil
 	}
 
+	if t, ok := value.(timeAs); ok {
+		i.WriteString(i.EncodeString(t.t.UTC().Format(t.layout)))
+		return nil
+	}
+
 	if valuer, ok := value.(driver.Valuer); ok {
 		// get driver.Valuer's data
 		var err error
@@ -0,0 +1,27 @@
+package dbr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gavin2014/lib/go/dbr/dialect"
+)
+
+func TestInterpolateInList(t *testing.T) {
+	value := []interface{}{"it's", `a\b`, 1, []byte("x"), time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	for _, test := range []struct {
+		d     Dialect
+		query string
+	}{
+		{dialect.MySQL, `a IN ('it\'s','a\\b',1,0x78,'2024-01-02 03:04:05.000000')`},
+		{dialect.PostgreSQL, `a IN ('it''s','a\b',1,E'\\x78','2024-01-02 03:04:05.000000')`},
+	} {
+		query, err := InterpolateForDialect("a IN ?", []interface{}{value}, test.d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if query != test.query {
+			t.Errorf("query = %s, want %s", query, test.query)
+		}
+	}
+}
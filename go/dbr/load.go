@@ -14,6 +14,47 @@ func InterfaceLoader(value interface{}, concreteType interface{}) interface{} {
 	return interfaceLoader{value, reflect.TypeOf(concreteType)}
 }
 
+// limitLoader loads at most n rows into v.
+type limitLoader struct {
+	v interface{}
+	n int
+}
+
+// oneLoader loads the first row into v like Load, and counts
+// a second row if there is one, so LoadOne can report it.
+type oneLoader struct {
+	v interface{}
+}
+
+// checkOne returns the error of LoadOne for the count of oneLoader.
+func checkOne(count int) error {
+	switch {
+	case count == 0:
+		return ErrNotFound
+	case count > 1:
+		return ErrMultipleRows
+	}
+	return nil
+}
+
+// returnLoader scans the n-th row into the n-th list of pointers.
+type returnLoader [][]interface{}
+
+func (into returnLoader) load(rows *sql.Rows) (int, error) {
+	count := 0
+	for count < len(into) && rows.Next() {
+		if err := rows.Scan(into[count]...); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, rows.Err()
+}
+
+// RowMap is a row loaded by column name. Unlike other maps,
+// Load does not key a RowMap by the first column.
+type RowMap map[string]interface{}
+
 // Load loads any value from sql.Rows.
 //
 // value can be:
@@ -28,6 +69,13 @@ func InterfaceLoader(value interface{}, concreteType interface{}) interface{} {
 //
 // 4. map of slice; like map, values with the same key are
 // collected with a slice.
+//
+// 5. RowMap; the first row is loaded by column name.
+// A slice of RowMap or map[string]interface{} loads every row this way.
+// []byte values are loaded as string.
+//
+// A time.Time struct field with a `dblayout` tag is parsed from
+// a string column with the layout, e.g. `dblayout:"2006-01-02"`.
 func Load(rows *sql.Rows, value interface{}) (int, error) {
 	defer rows.Close()
 
@@ -40,6 +88,18 @@ func Load(rows *sql.Rows, value interface{}) (int, error) {
 	var v reflect.Value
 	var elemType reflect.Type
 
+	if into, ok := value.(*returnLoader); ok {
+		return into.load(rows)
+	}
+
+	limit := 0
+	if ll, ok := value.(limitLoader); ok {
+		value, limit = ll.v, ll.n
+	}
+	one := false
+	if ol, ok := value.(oneLoader); ok {
+		value, one = ol.v, true
+	}
 	if il, ok := value.(interfaceLoader); ok {
 		v = reflect.ValueOf(il.v)
 		elemType = il.typ
@@ -53,7 +113,7 @@ func Load(rows *sql.Rows, value interface{}) (int, error) {
 	v = v.Elem()
 	isScanner := v.Addr().Type().Implements(typeScanner)
 	isSlice := v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 && !isScanner
-	isMap := v.Kind() == reflect.Map && !isScanner
+	isMap := v.Kind() == reflect.Map && !isScanner && v.Type() != typeRowMap
 	isMapOfSlices := isMap && v.Type().Elem().Kind() == reflect.Slice && v.Type().Elem().Elem().Kind() != reflect.Uint8
 	if isMap {
 		v.Set(reflect.MakeMap(v.Type()))
@@ -102,12 +162,10 @@ func Load(rows *sql.Rows, value interface{}) (int, error) {
 			return 0, err
 		}
 		count++
+		if elem.Kind() == reflect.Map && !isMap {
+			setRowMap(elem, column, ptr)
+		}
 		if isSlice {
-			if elem.Kind()==reflect.Map{
-				for i,name:= range column{
-					elem.SetMapIndex(reflect.ValueOf(name),reflect.ValueOf(ptr[i]).Elem())
-				}
-			}
 			v.Set(reflect.Append(v, elem))
 
 		} else if isMapOfSlices {
@@ -119,6 +177,12 @@ func Load(rows *sql.Rows, value interface{}) (int, error) {
 		} else if isMap {
 			v.SetMapIndex(keyElem, elem)
 		} else {
+			if one && rows.Next() {
+				count++
+			}
+			break
+		}
+		if limit > 0 && count >= limit {
 			break
 		}
 		for i := range ptr {
@@ -128,6 +192,21 @@ func Load(rows *sql.Rows, value interface{}) (int, error) {
 	return count, nil
 }
 
+// setRowMap sets the scanned values of a row to m by column name.
+// []byte is converted to string for interface{} values,
+// since drivers like MySQL return text columns as []byte.
+func setRowMap(m reflect.Value, column []string, ptr []interface{}) {
+	for i, name := range column {
+		v := reflect.ValueOf(ptr[i]).Elem()
+		if v.Kind() == reflect.Interface {
+			if b, ok := v.Interface().([]byte); ok {
+				v = reflect.ValueOf(string(b))
+			}
+		}
+		m.SetMapIndex(reflect.ValueOf(name), v)
+	}
+}
+
 func reflectAlloc(typ reflect.Type) reflect.Value {
 	if typ.Kind() == reflect.Ptr {
 		return reflect.New(typ.Elem())
@@ -144,4 +223,5 @@ func (dummyScanner) Scan(interface{}) error {
 var (
 	dummyDest   sql.Scanner = dummyScanner{}
 	typeScanner             = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	typeRowMap              = reflect.TypeOf(RowMap{})
 )
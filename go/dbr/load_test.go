@@ -0,0 +1,75 @@
+package dbr
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+
+	"github.com/gavin2014/lib/go/dbr/dialect"
+)
+
+func TestLoadMapByFirstColumn(t *testing.T) {
+	sess, db := newTestSession(dialect.MySQL)
+	db.addRows([]string{"name", "n"},
+		[]driver.Value{"a", int64(1)},
+		[]driver.Value{"b", int64(2)},
+	)
+	m := map[string]interface{}{}
+	n, err := sess.Select("name", "n").From("t").Load(&m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (map[string]interface{}{"a": int64(1), "b": int64(2)}); n != 2 || !reflect.DeepEqual(m, want) {
+		t.Fatalf("Load() = %d, %v, want %v", n, m, want)
+	}
+}
+
+func TestLoadRowMap(t *testing.T) {
+	sess, db := newTestSession(dialect.MySQL)
+	db.addRows([]string{"id", "name"},
+		[]driver.Value{int64(1), []byte("a")},
+		[]driver.Value{int64(2), []byte("b")},
+	)
+	var row RowMap
+	n, err := sess.Select("id", "name").From("t").Load(&row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (RowMap{"id": int64(1), "name": "a"}); n != 1 || !reflect.DeepEqual(row, want) {
+		t.Fatalf("Load() = %d, %v, want %v", n, row, want)
+	}
+
+	db.addRows([]string{"id", "name"},
+		[]driver.Value{int64(1), []byte("a")},
+		[]driver.Value{int64(2), []byte("b")},
+	)
+	var rows []RowMap
+	if _, err := sess.Select("id", "name").From("t").Load(&rows); err != nil {
+		t.Fatal(err)
+	}
+	want := []RowMap{{"id": int64(1), "name": "a"}, {"id": int64(2), "name": "b"}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("Load() = %v, want %v", rows, want)
+	}
+}
+
+func TestInsertLoadReturning(t *testing.T) {
+	sess, db := newTestSession(dialect.PostgreSQL)
+	db.addRows([]string{"id"}, []driver.Value{int64(7)})
+	var id int64
+	if err := sess.InsertInto("t").Columns("a").Values(1).Returning("id").Load(&id); err != nil {
+		t.Fatal(err)
+	}
+	if id != 7 {
+		t.Fatalf("id = %d, want 7", id)
+	}
+
+	db.addRows([]string{"id"}, []driver.Value{int64(8)}, []driver.Value{int64(9)})
+	var ids []int64
+	if err := sess.InsertInto("t").Columns("a").Values(1).Values(2).Returning("id").Load(&ids); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ids, []int64{8, 9}) {
+		t.Fatalf("ids = %v, want [8 9]", ids)
+	}
+}
@@ -0,0 +1,121 @@
+package dbr
+
+import (
+	"sync"
+	"time"
+)
+
+// RecordedEvent is an event stored by RecordingEventReceiver.
+// Args holds the values bound to the placeholders of SQL, e.g. with
+// CacheStatements, and is empty if the values are interpolated into SQL.
+type RecordedEvent struct {
+	Name     string
+	SQL      string
+	Args     string
+	Duration time.Duration
+	Err      error
+	Kvs      map[string]string
+}
+
+// RecordingEventReceiver stores every event in memory, e.g. to assert
+// the generated SQL in tests. Combined with DryRun, statements are
+// recorded without a database:
+//
+//	rec := &dbr.RecordingEventReceiver{}
+//	sess := conn.NewSession(rec)
+//	sess.DryRun(true)
+//	sess.DeleteFrom("users").Where("id = ?", 1).Exec()
+//	rec.LastQuery() // "DELETE FROM `users` WHERE (id = 1)"
+//
+// It is safe for concurrent use.
+type RecordingEventReceiver struct {
+	mu     sync.Mutex
+	events []RecordedEvent
+}
+
+func (r *RecordingEventReceiver) record(eventName string, nanoseconds int64, err error, kvs map[string]string) {
+	e := RecordedEvent{
+		Name:     eventName,
+		SQL:      kvs["sql"],
+		Args:     kvs["args"],
+		Duration: time.Duration(nanoseconds),
+		Err:      err,
+		Kvs:      kvs,
+	}
+	r.mu.Lock()
+	r.events = append(r.events, e)
+	r.mu.Unlock()
+}
+
+// Event records a simple notification.
+func (r *RecordingEventReceiver) Event(eventName string) {
+	r.record(eventName, 0, nil, nil)
+}
+
+// EventKv records a notification with key/value data.
+func (r *RecordingEventReceiver) EventKv(eventName string, kvs map[string]string) {
+	r.record(eventName, 0, nil, kvs)
+}
+
+// EventErr records an error, and returns it.
+func (r *RecordingEventReceiver) EventErr(eventName string, err error) error {
+	r.record(eventName, 0, err, nil)
+	return err
+}
+
+// EventErrKv records an error with key/value data, and returns it.
+func (r *RecordingEventReceiver) EventErrKv(eventName string, err error, kvs map[string]string) error {
+	r.record(eventName, 0, err, kvs)
+	return err
+}
+
+// Timing records the time an event took.
+func (r *RecordingEventReceiver) Timing(eventName string, nanoseconds int64) {
+	r.record(eventName, nanoseconds, nil, nil)
+}
+
+// TimingKv records the time an event took with key/value data.
+func (r *RecordingEventReceiver) TimingKv(eventName string, nanoseconds int64, kvs map[string]string) {
+	r.record(eventName, nanoseconds, nil, kvs)
+}
+
+// Events returns the recorded events in order.
+func (r *RecordingEventReceiver) Events() []RecordedEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]RecordedEvent(nil), r.events...)
+}
+
+// Queries returns the SQL of the recorded events in order,
+// leaving out events without SQL.
+func (r *RecordingEventReceiver) Queries() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var query []string
+	for _, e := range r.events {
+		if e.SQL != "" {
+			query = append(query, e.SQL)
+		}
+	}
+	return query
+}
+
+// LastQuery returns the SQL of the last event with SQL,
+// or "" if there is none.
+func (r *RecordingEventReceiver) LastQuery() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.events) - 1; i >= 0; i-- {
+		if r.events[i].SQL != "" {
+			return r.events[i].SQL
+		}
+	}
+	return ""
+}
+
+// Reset removes the recorded events.
+func (r *RecordingEventReceiver) Reset() {
+	r.mu.Lock()
+	r.events = nil
+	r.mu.Unlock()
+}
@@ -0,0 +1,69 @@
+package dbr
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/gavin2014/lib/go/dbr/dialect"
+)
+
+func TestRecordingEventReceiver(t *testing.T) {
+	sess, _ := newTestSession(dialect.MySQL)
+	rec := &RecordingEventReceiver{}
+	sess.EventReceiver = rec
+	sess.DryRun(true)
+
+	if _, err := sess.DeleteFrom("users").Where("id = ?", 1).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rec.LastQuery(), "DELETE FROM `users` WHERE (id = 1)"; got != want {
+		t.Fatalf("LastQuery() = %s, want %s", got, want)
+	}
+	if e := rec.Events()[0]; e.Name != "dbr.exec.dry_run" || e.Args != "" {
+		t.Fatalf("event = %+v, want dbr.exec.dry_run without args", e)
+	}
+
+	// bound values are recorded with the SQL of their placeholders
+	sess.CacheStatements(true)
+	if _, err := sess.DeleteFrom("users").Where("id = ?", 2).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if e := rec.Events()[1]; e.SQL != "DELETE FROM `users` WHERE (id = ?)" || e.Args != "[2]" {
+		t.Fatalf("event = %+v, want the bound args", e)
+	}
+
+	rec.Reset()
+	if len(rec.Events()) != 0 || rec.LastQuery() != "" {
+		t.Fatal("Reset kept events")
+	}
+}
+
+func TestRecordingEventReceiverConcurrent(t *testing.T) {
+	sess, _ := newTestSession(dialect.MySQL)
+	rec := &RecordingEventReceiver{}
+	sess.EventReceiver = rec
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var n []int
+			sess.Select("n").From("t").Where("id = ?", i).LoadContext(context.Background(), &n)
+			rec.LastQuery()
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool)
+	for _, query := range rec.Queries() {
+		seen[query] = true
+	}
+	for i := 0; i < 20; i++ {
+		if query := "SELECT n FROM t WHERE (id = " + strconv.Itoa(i) + ")"; !seen[query] {
+			t.Errorf("%s was not recorded", query)
+		}
+	}
+}
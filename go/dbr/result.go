@@ -0,0 +1,42 @@
+package dbr
+
+import "database/sql"
+
+// ExecResult wraps sql.Result returned by ExecR.
+// If the driver cannot report a value, or nothing was executed,
+// the fallback set with Session.SetResultFallback is returned instead of an error.
+type ExecResult struct {
+	Result   sql.Result
+	fallback int64
+}
+
+func newExecResult(runner runner, result sql.Result) *ExecResult {
+	return &ExecResult{
+		Result:   result,
+		fallback: runner.GetResultFallback(),
+	}
+}
+
+// RowsAffected returns the number of rows affected by the statement.
+func (r *ExecResult) RowsAffected() int64 {
+	if r.Result == nil {
+		return r.fallback
+	}
+	n, err := r.Result.RowsAffected()
+	if err != nil {
+		return r.fallback
+	}
+	return n
+}
+
+// LastInsertID returns the id of the last inserted row.
+func (r *ExecResult) LastInsertID() int64 {
+	if r.Result == nil {
+		return r.fallback
+	}
+	id, err := r.Result.LastInsertId()
+	if err != nil {
+		return r.fallback
+	}
+	return id
+}
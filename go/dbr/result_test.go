@@ -0,0 +1,36 @@
+package dbr
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/gavin2014/lib/go/dbr/dialect"
+)
+
+func TestExecR(t *testing.T) {
+	sess, db := newTestSession(dialect.MySQL)
+	db.lastID = 42
+	db.affected = []int64{3}
+	r, err := sess.Update("t").Set("a", 1).Where("b = ?", 2).ExecR(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.RowsAffected() != 3 || r.LastInsertID() != 42 {
+		t.Fatalf("ExecR() = %d rows, id %d", r.RowsAffected(), r.LastInsertID())
+	}
+}
+
+func TestExecResultFallback(t *testing.T) {
+	sess, _ := newTestSession(dialect.PostgreSQL)
+	sess.SetResultFallback(-1)
+	// driver.RowsAffected cannot report the last insert id
+	r := newExecResult(sess, driver.RowsAffected(2))
+	if r.RowsAffected() != 2 || r.LastInsertID() != -1 {
+		t.Fatalf("result = %d rows, id %d", r.RowsAffected(), r.LastInsertID())
+	}
+	r = newExecResult(sess, nil)
+	if r.RowsAffected() != -1 || r.LastInsertID() != -1 {
+		t.Fatalf("nil result = %d rows, id %d", r.RowsAffected(), r.LastInsertID())
+	}
+}
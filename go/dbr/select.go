@@ -3,8 +3,11 @@ package dbr
 import (
 	"context"
 	"database/sql"
-	"strconv"
+	"fmt"
+	"strings"
 	"time"
+
+	"github.com/gavin2014/lib/go/dbr/dialect"
 )
 
 // SelectStmt builds `SELECT ...`.
@@ -29,11 +32,29 @@ type SelectStmt struct {
 
 	LimitCount  int64
 	OffsetCount int64
+
+	QueryLabel  string
+	Partition   PartitionFunc
+	TableMapper func(string) string
+
+	lockMode string
+	err      error
 }
 
+// row-level lock clauses
+const (
+	lockUpdate      = "FOR UPDATE"
+	lockNoKeyUpdate = "FOR NO KEY UPDATE"
+	lockKeyShare    = "FOR KEY SHARE"
+)
+
 type SelectBuilder = SelectStmt
 
 func (b *SelectStmt) Build(d Dialect, buf Buffer) error {
+	if b.err != nil {
+		return b.err
+	}
+
 	if b.raw.Query != "" {
 		return b.raw.Build(d, buf)
 	}
@@ -71,7 +92,7 @@ func (b *SelectStmt) Build(d Dialect, buf Buffer) error {
 			buf.WriteString(")")
 		case string:
 			// FIXME: no quote ident
-			buf.WriteString(table)
+			buf.WriteString(mapTable(b.TableMapper, table))
 		default:
 			buf.WriteString(placeholder)
 			buf.WriteValue(table)
@@ -132,22 +153,28 @@ func (b *SelectStmt) Build(d Dialect, buf Buffer) error {
 		}
 	}
 
-	if b.LimitCount >= 0 {
-		buf.WriteString(" LIMIT ")
-		buf.WriteString(strconv.FormatInt(b.LimitCount, 10))
-	}
-
-	if b.OffsetCount >= 0 {
-		buf.WriteString(" OFFSET ")
-		buf.WriteString(strconv.FormatInt(b.OffsetCount, 10))
+	if clause := d.LimitOffset(b.LimitCount, b.OffsetCount); clause != "" {
+		buf.WriteString(" ")
+		buf.WriteString(clause)
 	}
 	//如果未设置Lock.并且是实物
+	lock := false
 	if b.IsLock == nil {
-		if _, ok := b.runner.(*Tx); ok {
-			buf.WriteString(" FOR UPDATE ")
+		_, lock = b.runner.(*Tx)
+	} else {
+		lock = *b.IsLock
+	}
+	if lock {
+		clause := lockUpdate
+		if b.lockMode != "" {
+			if !isDialect(d, dialect.PostgreSQL) {
+				return fmt.Errorf("%w: %s, use Lock instead", ErrNotSupported, b.lockMode)
+			}
+			clause = b.lockMode
 		}
-	} else if *b.IsLock {
-		buf.WriteString(" FOR UPDATE ")
+		buf.WriteString(" ")
+		buf.WriteString(clause)
+		buf.WriteString(" ")
 	}
 	return nil
 }
@@ -175,6 +202,8 @@ func (sess *Session) Select(column ...string) *SelectStmt {
 	b.runner = sess
 	b.EventReceiver = sess.EventReceiver
 	b.Dialect = sess.Dialect
+	b.Partition = sess.partition
+	b.TableMapper = sess.tableMapper
 	return b
 }
 
@@ -184,6 +213,48 @@ func (tx *Tx) Select(column ...string) *SelectStmt {
 	b.runner = tx
 	b.EventReceiver = tx.EventReceiver
 	b.Dialect = tx.Dialect
+	b.Partition = tx.partition
+	b.TableMapper = tx.tableMapper
+	return b
+}
+
+// SelectStruct creates a SelectStmt with the columns of structValue,
+// found with ColumnsOf, so the projection matches the fields Load scans into.
+// Like Load, the fields of an embedded struct pointer are only loaded
+// if it is not nil.
+func SelectStruct(structValue interface{}) *SelectStmt {
+	return Select(structColumns(structValue)...)
+}
+
+// SelectStruct creates a SelectStmt with the columns of structValue.
+func (sess *Session) SelectStruct(structValue interface{}) *SelectStmt {
+	b := SelectStruct(structValue)
+	b.runner = sess
+	b.EventReceiver = sess.EventReceiver
+	b.Dialect = sess.Dialect
+	b.Partition = sess.partition
+	b.TableMapper = sess.tableMapper
+	return b
+}
+
+// SelectStruct creates a SelectStmt with the columns of structValue.
+func (tx *Tx) SelectStruct(structValue interface{}) *SelectStmt {
+	b := SelectStruct(structValue)
+	b.runner = tx
+	b.EventReceiver = tx.EventReceiver
+	b.Dialect = tx.Dialect
+	b.Partition = tx.partition
+	b.TableMapper = tx.tableMapper
+	return b
+}
+
+// structColumns returns the columns of structValue as quoted identifiers.
+func structColumns(structValue interface{}) []interface{} {
+	column := ColumnsOf(structValue)
+	b := make([]interface{}, len(column))
+	for i := range column {
+		b[i] = I(column[i])
+	}
 	return b
 }
 
@@ -227,6 +298,16 @@ func (b *SelectStmt) From(table interface{}, as ...string) *SelectStmt {
 	return b
 }
 
+// FromPartition is like From, but selects from the table Partition returns
+// for table and row, e.g. `events_2024_01` for the month in row["ts"].
+// Without Partition, table is used as is.
+func (b *SelectStmt) FromPartition(table string, row map[string]interface{}, as ...string) *SelectStmt {
+	if b.Partition != nil {
+		table = b.Partition(table, row)
+	}
+	return b.From(table, as...)
+}
+
 func (b *SelectStmt) Distinct() *SelectStmt {
 	b.IsDistinct = true
 	return b
@@ -237,6 +318,22 @@ func (b *SelectStmt) Lock(l bool) *SelectStmt {
 	return b
 }
 
+// ForNoKeyUpdate locks the selected rows with `FOR NO KEY UPDATE`,
+// which does not block foreign key checks on them.
+// It is only supported by PostgreSQL, and Build returns ErrNotSupported otherwise.
+func (b *SelectStmt) ForNoKeyUpdate() *SelectStmt {
+	b.lockMode = lockNoKeyUpdate
+	return b.Lock(true)
+}
+
+// ForKeyShare locks the selected rows with `FOR KEY SHARE`,
+// which only blocks changes to their keys.
+// It is only supported by PostgreSQL, and Build returns ErrNotSupported otherwise.
+func (b *SelectStmt) ForKeyShare() *SelectStmt {
+	b.lockMode = lockKeyShare
+	return b.Lock(true)
+}
+
 // Where adds a where condition.
 // query can be Builder or string. value is used only if query type is string.
 func (b *SelectStmt) Where(query interface{}, value ...interface{}) *SelectStmt {
@@ -249,6 +346,12 @@ func (b *SelectStmt) Where(query interface{}, value ...interface{}) *SelectStmt
 	return b
 }
 
+// WhereInStructs adds `column IN (...)` with the values of field
+// of each struct in slice. See InStructs.
+func (b *SelectStmt) WhereInStructs(column string, slice interface{}, field string) *SelectStmt {
+	return b.Where(InStructs(column, slice, field))
+}
+
 // Having adds a having condition.
 // query can be Builder or string. value is used only if query type is string.
 func (b *SelectStmt) Having(query interface{}, value ...interface{}) *SelectStmt {
@@ -285,6 +388,31 @@ func (b *SelectStmt) OrderBy(col string) *SelectStmt {
 	return b
 }
 
+// OrderBySpec adds the ordering of a sort parameter like `-created,name`,
+// i.e. comma-separated fields, each with an optional `-` prefix for DESC.
+// allowed maps each field that can be sorted by to its column.
+// If spec has a field that is not in allowed, ErrInvalidSort is
+// returned when the statement is built.
+func (b *SelectStmt) OrderBySpec(spec string, allowed map[string]string) *SelectStmt {
+	if spec == "" {
+		return b
+	}
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		dir := asc
+		if strings.HasPrefix(field, "-") {
+			field, dir = field[1:], desc
+		}
+		column, ok := allowed[field]
+		if !ok {
+			b.err = fmt.Errorf("%w: unknown field %q", ErrInvalidSort, field)
+			return b
+		}
+		b.Order = append(b.Order, order(column, dir))
+	}
+	return b
+}
+
 func (b *SelectStmt) Limit(n uint64) *SelectStmt {
 	b.LimitCount = int64(n)
 	return b
@@ -340,11 +468,21 @@ func (b *SelectStmt) FullJoin(table, on interface{}) *SelectStmt {
 	return b
 }
 
+// Label names the statement, e.g. "users.list".
+// The label is added to the event kvs as "label", and written as
+// a leading SQL comment if CommentLabel is enabled.
+func (b *SelectStmt) Label(name string) *SelectStmt {
+	b.QueryLabel = name
+	return b
+}
+
 //获取SQL
-func (b *SelectStmt) GetSQL() (string, error) {
+// An optional dialect like NoQuote(nil) overrides the dialect of the statement.
+// A statement created without a session is written with MySQL by default.
+func (b *SelectStmt) GetSQL(d ...Dialect) (string, error) {
 	b1 := *b
 	b2 := &b1
-	return getSQL(b2, b2.Dialect)
+	return getSQL(b2, previewDialect(b2.Dialect, d))
 }
 
 //获取总条数
@@ -366,10 +504,10 @@ func (b *SelectStmt) Rows() (*sql.Rows, error) {
 
 func (b *SelectStmt) RowsContext(ctx context.Context) (*sql.Rows, error) {
 	startTime := time.Now()
-	query, rows, err := queryRows(ctx, b.runner, b.EventReceiver, b, b.Dialect)
-	b.EventReceiver.TimingKv("dbr.select", time.Since(startTime).Nanoseconds(), kvs{
-		"sql": query,
-	})
+	query, value, rows, err := queryRows(ctx, b.runner, b.EventReceiver, b, b.Dialect)
+	if timingEnabled(b.EventReceiver) {
+		b.EventReceiver.TimingKv("dbr.select", time.Since(startTime).Nanoseconds(), eventKvs(ctx, b, queryKvs(query, logArgs(b.runner, value))))
+	}
 	return rows, err
 }
 
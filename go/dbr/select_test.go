@@ -0,0 +1,157 @@
+package dbr
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gavin2014/lib/go/dbr/dialect"
+)
+
+func TestSelectFromPartition(t *testing.T) {
+	sess, db := newTestSession(dialect.MySQL)
+	sess.PartitionRouter(monthPartition)
+	var n []int
+	_, err := sess.Select("n").
+		FromPartition("events", map[string]interface{}{"month": "2024_02"}).
+		Where("n > ?", 1).
+		Load(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkQueries(t, db, "SELECT n FROM events_2024_02 WHERE (n > 1)")
+}
+
+func TestSelectLockMode(t *testing.T) {
+	for _, test := range []struct {
+		stmt  *SelectStmt
+		query string
+	}{
+		{Select("id").From("t").Lock(true), "SELECT id FROM t FOR UPDATE "},
+		{Select("id").From("t").ForNoKeyUpdate(), "SELECT id FROM t FOR NO KEY UPDATE "},
+		{Select("id").From("t").ForKeyShare(), "SELECT id FROM t FOR KEY SHARE "},
+	} {
+		checkBuild(t, test.stmt, dialect.PostgreSQL, test.query)
+	}
+	for clause, stmt := range map[string]*SelectStmt{
+		"FOR NO KEY UPDATE": Select("id").From("t").ForNoKeyUpdate(),
+		"FOR KEY SHARE":     Select("id").From("t").ForKeyShare(),
+	} {
+		err := stmt.Build(dialect.MySQL, NewBuffer())
+		if !errors.Is(err, ErrNotSupported) || !strings.Contains(err.Error(), clause) {
+			t.Errorf("Build on MySQL = %v, want ErrNotSupported naming %s", err, clause)
+		}
+	}
+}
+
+func TestSelectStructPartition(t *testing.T) {
+	sess, db := newTestSession(dialect.MySQL)
+	sess.PartitionRouter(monthPartition)
+	type event struct{ N int }
+	var rows []event
+	_, err := sess.SelectStruct(event{}).
+		FromPartition("events", map[string]interface{}{"month": "2024_03"}).
+		Load(&rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkQueries(t, db, "SELECT `n` FROM events_2024_03")
+}
+
+type account struct {
+	ID       int64
+	Name     string `db:"name"`
+	Ignored  string `db:"-"`
+	internal int
+	*AccountContact
+}
+
+type AccountContact struct {
+	Email string
+}
+
+func TestSelectStruct(t *testing.T) {
+	if got, want := fmt.Sprint(ColumnsOf(&account{})), "[id name email]"; got != want {
+		t.Fatalf("ColumnsOf() = %s, want %s", got, want)
+	}
+
+	sess, db := newTestSession(dialect.MySQL)
+	db.addRows([]string{"id", "name", "email"}, []driver.Value{int64(1), "a", "a@b.c"})
+	// like Load, an embedded pointer is only loaded if it is set
+	a := account{AccountContact: &AccountContact{}}
+	if err := sess.SelectStruct(a).From("accounts").LoadOne(&a); err != nil {
+		t.Fatal(err)
+	}
+	checkQueries(t, db, "SELECT `id`, `name`, `email` FROM accounts")
+	if a.ID != 1 || a.Name != "a" || a.Email != "a@b.c" {
+		t.Fatalf("loaded %+v", a)
+	}
+}
+
+func TestSelectOrderBySpec(t *testing.T) {
+	allowed := map[string]string{"created": "created_at", "name": "users.name"}
+	stmt := Select("*").From("users").OrderBySpec("-created, name", allowed)
+	checkBuild(t, stmt, dialect.MySQL, "SELECT * FROM users ORDER BY created_at DESC, users.name ASC")
+
+	for _, spec := range []string{"password", "-created,name;DROP TABLE users", "name,"} {
+		stmt := Select("*").From("users").OrderBySpec(spec, allowed)
+		if err := stmt.Build(dialect.MySQL, NewBuffer()); !errors.Is(err, ErrInvalidSort) {
+			t.Errorf("OrderBySpec(%q): Build() = %v, want ErrInvalidSort", spec, err)
+		}
+	}
+}
+
+// fetchDialect renders LIMIT and OFFSET like SQL Server.
+type fetchDialect struct {
+	Dialect
+}
+
+func (fetchDialect) LimitOffset(limit, offset int64) string {
+	if offset < 0 {
+		offset = 0
+	}
+	clause := fmt.Sprintf("OFFSET %d ROWS", offset)
+	if limit >= 0 {
+		clause += fmt.Sprintf(" FETCH NEXT %d ROWS ONLY", limit)
+	}
+	return clause
+}
+
+func TestSelectLimitOffset(t *testing.T) {
+	for _, test := range []struct {
+		d     Dialect
+		stmt  *SelectStmt
+		query string
+	}{
+		{dialect.MySQL, Select("id").From("t").Limit(10).Offset(20), "SELECT id FROM t LIMIT 10 OFFSET 20"},
+		{dialect.PostgreSQL, Select("id").From("t").Limit(10), "SELECT id FROM t LIMIT 10"},
+		{dialect.SQLite3, Select("id").From("t"), "SELECT id FROM t"},
+		{fetchDialect{dialect.MySQL}, Select("id").From("t").OrderBy("id").Limit(10).Offset(20),
+			"SELECT id FROM t ORDER BY id OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY"},
+	} {
+		checkBuild(t, test.stmt, test.d, test.query)
+	}
+}
+
+func TestTxSelectFromPartition(t *testing.T) {
+	sess, db := newTestSession(dialect.MySQL)
+	sess.PartitionRouter(monthPartition)
+	tx, err := sess.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var n []int
+	_, err = tx.Select("n").
+		FromPartition("events", map[string]interface{}{"month": "2024_02"}).
+		Lock(false).
+		Load(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	checkQueries(t, db, "BEGIN", "SELECT n FROM events_2024_02", "COMMIT")
+}
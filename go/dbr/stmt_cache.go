@@ -0,0 +1,135 @@
+package dbr
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+)
+
+// maxCachedStmts limits the number of prepared statements cached per Connection.
+// Statements beyond it are prepared and closed for each use.
+const maxCachedStmts = 1000
+
+// stmtCache caches prepared statements by their SQL.
+// It is safe for concurrent use.
+type stmtCache struct {
+	db *sql.DB
+
+	mu   sync.Mutex
+	stmt map[string]*sql.Stmt
+}
+
+func newStmtCache(db *sql.DB) *stmtCache {
+	return &stmtCache{
+		db:   db,
+		stmt: make(map[string]*sql.Stmt),
+	}
+}
+
+// prepare returns the cached statement for query, or prepares it.
+// If the cache is full, the statement is not cached and should be closed after use.
+func (c *stmtCache) prepare(ctx context.Context, query string) (*sql.Stmt, bool, error) {
+	c.mu.Lock()
+	stmt, ok := c.stmt[query]
+	c.mu.Unlock()
+	if ok {
+		return stmt, true, nil
+	}
+
+	stmt, err := c.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, false, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cached, ok := c.stmt[query]; ok {
+		// prepared concurrently
+		stmt.Close()
+		return cached, true, nil
+	}
+	if len(c.stmt) >= maxCachedStmts {
+		return stmt, false, nil
+	}
+	c.stmt[query] = stmt
+	return stmt, true, nil
+}
+
+// evict removes stmt from the cache and closes it.
+func (c *stmtCache) evict(query string, stmt *sql.Stmt) {
+	c.mu.Lock()
+	if c.stmt[query] == stmt {
+		delete(c.stmt, query)
+	}
+	c.mu.Unlock()
+	stmt.Close()
+}
+
+// close closes the cached statements and empties the cache.
+func (c *stmtCache) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var err error
+	for query, stmt := range c.stmt {
+		if e := stmt.Close(); e != nil && err == nil {
+			err = e
+		}
+		delete(c.stmt, query)
+	}
+	return err
+}
+
+// exec executes query with a prepared statement, in tx if it is not nil.
+// The statement is evicted if its connection is lost.
+func (c *stmtCache) exec(ctx context.Context, tx *sql.Tx, query string, args []interface{}) (sql.Result, error) {
+	stmt, cached, err := c.prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if !cached {
+		defer stmt.Close()
+	}
+	s := stmt
+	if tx != nil {
+		s = tx.StmtContext(ctx, stmt)
+		defer s.Close()
+	}
+	result, err := s.ExecContext(ctx, args...)
+	if cached && errors.Is(err, driver.ErrBadConn) {
+		c.evict(query, stmt)
+	}
+	return result, err
+}
+
+// CacheStatements sets whether statements executed in the session
+// reuse prepared statements cached on the Connection, which saves
+// the database from parsing the same SQL again.
+//
+// When it is enabled, values are bound to placeholders instead of
+// being interpolated, so statements of the same shape share their SQL.
+// Only Exec uses the cache; Load and Rows are not affected.
+// The cache is only available on a Connection created with Open,
+// and its statements are closed by Connection.Close.
+func (sess *Session) CacheStatements(enable bool) {
+	sess.cacheStmts = enable
+}
+
+func (sess *Session) preparedCache() *stmtCache {
+	if !sess.cacheStmts {
+		return nil
+	}
+	return sess.Connection.stmts
+}
+
+// runnerCache returns the statement cache of runner if it is enabled,
+// and the transaction to execute in.
+func runnerCache(runner runner) (*stmtCache, *sql.Tx) {
+	switch r := runner.(type) {
+	case *Session:
+		return r.preparedCache(), nil
+	case *Tx:
+		return r.stmts, r.Tx
+	}
+	return nil, nil
+}
@@ -0,0 +1,42 @@
+package dbr
+
+import (
+	"testing"
+
+	"github.com/gavin2014/lib/go/dbr/dialect"
+)
+
+func TestCacheStatements(t *testing.T) {
+	sess, db := newTestSession(dialect.PostgreSQL)
+	sess.CacheStatements(true)
+	for i := 0; i < 10; i++ {
+		// map order must not change the SQL
+		_, err := sess.Update("t").
+			SetMap(map[string]interface{}{"a": i, "b": i, "c": i, "d": i}).
+			Where("id = ?", i).
+			Exec()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	q := db.Queries()
+	if len(q) != 10 || q[0] != `UPDATE "t" SET "a" = $1, "b" = $2, "c" = $3, "d" = $4 WHERE (id = $5)` {
+		t.Fatalf("queries = %q", q)
+	}
+	if db.prepared != 1 {
+		t.Fatalf("%d statements prepared, want 1", db.prepared)
+	}
+	if n := len(sess.Connection.stmts.stmt); n != 1 {
+		t.Fatalf("%d statements cached, want 1", n)
+	}
+
+	if err := sess.Connection.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if db.closed != 1 {
+		t.Fatalf("%d statements closed, want 1", db.closed)
+	}
+	if n := len(sess.Connection.stmts.stmt); n != 0 {
+		t.Fatalf("%d statements cached after Close", n)
+	}
+}
@@ -0,0 +1,75 @@
+package dbr
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"time"
+)
+
+// timeLayout scans a string date column into a time.Time field
+// tagged with `dblayout`, e.g.
+//
+//	Birthday time.Time `db:"birthday" dblayout:"2006-01-02"`
+//
+// The string is parsed in UTC. NULL and empty strings scan to the zero time.
+type timeLayout struct {
+	t      *time.Time
+	layout string
+}
+
+func (l timeLayout) Scan(value interface{}) error {
+	var s string
+	switch v := value.(type) {
+	case nil:
+		*l.t = time.Time{}
+		return nil
+	case time.Time:
+		*l.t = v
+		return nil
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	default:
+		return ErrCantConvertToTime
+	}
+	if s == "" {
+		*l.t = time.Time{}
+		return nil
+	}
+	t, err := time.Parse(l.layout, s)
+	if err != nil {
+		return ErrInvalidTimestring
+	}
+	*l.t = t
+	return nil
+}
+
+// layoutValue returns a scan destination for a `dblayout` field if ptr is set,
+// or the field formatted with layout for writing.
+func layoutValue(field reflect.Value, layout string, ptr bool) interface{} {
+	if ptr {
+		return timeLayout{t: field.Addr().Interface().(*time.Time), layout: layout}
+	}
+	return reflect.ValueOf(field.Interface().(time.Time).Format(layout))
+}
+
+// TimeAs returns t to be interpolated as a string formatted with layout
+// in UTC like EncodeTime, e.g. with microseconds for an audit table:
+//
+//	dbr.TimeAs(t, "2006-01-02 15:04:05.000000")
+//
+// It only changes how t is written by GetSQL and interpolation.
+// When bound to a placeholder, t itself is sent.
+func TimeAs(t time.Time, layout string) driver.Valuer {
+	return timeAs{t: t, layout: layout}
+}
+
+type timeAs struct {
+	t      time.Time
+	layout string
+}
+
+func (v timeAs) Value() (driver.Value, error) {
+	return v.t, nil
+}
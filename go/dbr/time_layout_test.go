@@ -0,0 +1,47 @@
+package dbr
+
+import (
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/gavin2014/lib/go/dbr/dialect"
+)
+
+type person struct {
+	ID       int64
+	Birthday time.Time `db:"birthday" dblayout:"2006-01-02"`
+}
+
+func TestTimeLayoutRoundTrip(t *testing.T) {
+	sess, db := newTestSession(dialect.MySQL)
+	birthday := time.Date(1990, 5, 17, 0, 0, 0, 0, time.UTC)
+	_, err := sess.InsertInto("people").Columns("birthday").Record(&person{Birthday: birthday}).Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkQueries(t, db, "INSERT INTO `people` (`birthday`) VALUES ('1990-05-17')")
+
+	db.addRows([]string{"id", "birthday"}, []driver.Value{int64(1), []byte("1990-05-17")})
+	var p person
+	if _, err := sess.Select("*").From("people").Load(&p); err != nil {
+		t.Fatal(err)
+	}
+	if !p.Birthday.Equal(birthday) {
+		t.Fatalf("Birthday = %v, want %v", p.Birthday, birthday)
+	}
+}
+
+func TestTimeAs(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 123456000, time.UTC)
+	query, err := InterpolateForDialect("? ?", []interface{}{
+		TimeAs(at, "2006-01-02 15:04:05.000000"),
+		TimeAs(at, "2006-01-02"),
+	}, dialect.MySQL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "'2024-01-02 03:04:05.123456' '2024-01-02'"; query != want {
+		t.Fatalf("query = %s, want %s", query, want)
+	}
+}
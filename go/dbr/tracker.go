@@ -0,0 +1,120 @@
+package dbr
+
+import (
+	"context"
+	"reflect"
+)
+
+// Tracker records the column values of a loaded struct,
+// so only the changed columns are updated later.
+//
+//	var u User
+//	t, err := sess.Select("*").From("users").Where("id = ?", id).LoadTracked(&u)
+//	u.Name = "new name"
+//	sess.Update("users").SetChanged(t).Where("id = ?", id).Exec()
+type Tracker struct {
+	ptr      interface{}
+	snapshot map[string]interface{}
+}
+
+// Track snapshots the columns of ptr, which should be a pointer to a struct.
+// Columns are found the same way as Load and Record.
+//
+// Pointer fields are compared by the values they point to, so a value
+// changed through the pointer is detected. Values are compared with
+// reflect.DeepEqual, so a slice or map modified in place is not detected
+// as changed.
+func Track(ptr interface{}) *Tracker {
+	snapshot := structValues(reflect.ValueOf(ptr))
+	for col, v := range snapshot {
+		snapshot[col] = derefValue(v)
+	}
+	return &Tracker{
+		ptr:      ptr,
+		snapshot: snapshot,
+	}
+}
+
+// LoadTracked loads a row into ptr like LoadOne,
+// and returns a Tracker of ptr for SetChanged.
+func (b *SelectStmt) LoadTracked(ptr interface{}) (*Tracker, error) {
+	return b.LoadTrackedContext(context.Background(), ptr)
+}
+
+// LoadTrackedContext is like LoadTracked with ctx.
+func (b *SelectStmt) LoadTrackedContext(ctx context.Context, ptr interface{}) (*Tracker, error) {
+	if err := b.LoadOneContext(ctx, ptr); err != nil {
+		return nil, err
+	}
+	return Track(ptr), nil
+}
+
+// Changed returns the columns that changed since Track, with their new values.
+func (t *Tracker) Changed() map[string]interface{} {
+	changed := make(map[string]interface{})
+	for col, v := range structValues(reflect.ValueOf(t.ptr)) {
+		if old, ok := t.snapshot[col]; !ok || !reflect.DeepEqual(old, derefValue(v)) {
+			changed[col] = v
+		}
+	}
+	return changed
+}
+
+// derefValue returns the value v points to, or nil for a nil pointer.
+func derefValue(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil
+	}
+	return rv.Interface()
+}
+
+// SetChanged updates the columns that changed since t was created.
+// If nothing changed, ErrColumnNotSpecified is returned when the statement is built.
+func (b *UpdateStmt) SetChanged(t *Tracker) *UpdateStmt {
+	return b.SetMap(t.Changed())
+}
+
+// structValues returns the column values of a struct,
+// including its embedded structs.
+func structValues(value reflect.Value) map[string]interface{} {
+	m := make(map[string]interface{})
+	collectValues(newTagStore(), reflect.Indirect(value), m)
+	return m
+}
+
+func collectValues(s *tagStore, value reflect.Value, m map[string]interface{}) {
+	if value.Kind() != reflect.Struct {
+		return
+	}
+	l := s.get(value.Type())
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		if !field.CanInterface() {
+			continue
+		}
+		if value.Type().Field(i).Anonymous {
+			collectValues(s, reflect.Indirect(field), m)
+			continue
+		}
+		tag := l[i]
+		if tag.name == "" {
+			continue
+		}
+		if _, ok := m[tag.name]; ok {
+			// the first field wins, as in Load
+			continue
+		}
+		if tag.layout != "" {
+			m[tag.name] = layoutValue(field, tag.layout, false).(reflect.Value).Interface()
+			continue
+		}
+		m[tag.name] = field.Interface()
+	}
+}
@@ -0,0 +1,51 @@
+package dbr
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/gavin2014/lib/go/dbr/dialect"
+)
+
+type trackedUser struct {
+	ID       int64
+	Name     string
+	Nickname *string
+}
+
+func TestTrackerSetChanged(t *testing.T) {
+	sess, db := newTestSession(dialect.MySQL)
+	db.addRows([]string{"id", "name", "nickname"}, []driver.Value{int64(1), "a", "b"})
+	var u trackedUser
+	tr, err := sess.Select("*").From("users").Where("id = ?", 1).LoadTracked(&u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.Name = "c"
+	if _, err := sess.Update("users").SetChanged(tr).Where("id = ?", u.ID).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	checkQueries(t, db,
+		"SELECT * FROM users WHERE (id = 1)",
+		"UPDATE `users` SET `name` = 'c' WHERE (id = 1)",
+	)
+}
+
+func TestTrackerPointerField(t *testing.T) {
+	nick := "a"
+	u := trackedUser{ID: 1, Nickname: &nick}
+	tr := Track(&u)
+	if changed := tr.Changed(); len(changed) != 0 {
+		t.Fatalf("Changed() = %v before any change", changed)
+	}
+	*u.Nickname = "b"
+	changed := tr.Changed()
+	if len(changed) != 1 || *changed["nickname"].(*string) != "b" {
+		t.Fatalf("Changed() = %v, want nickname", changed)
+	}
+
+	u.Nickname = nil
+	if changed := tr.Changed(); len(changed) != 1 {
+		t.Fatalf("Changed() = %v, want nickname", changed)
+	}
+}
@@ -3,6 +3,7 @@ package dbr
 import (
 	"context"
 	"database/sql"
+	"strconv"
 	"time"
 )
 
@@ -12,6 +13,17 @@ type Tx struct {
 	Dialect
 	*sql.Tx
 	Timeout time.Duration
+
+	partition       PartitionFunc
+	insertBatchSize int
+	dryRun          bool
+	tableMapper     func(string) string
+	resultFallback  int64
+	quietChunks     bool
+	redactArgs      bool
+	stmts           *stmtCache
+
+	savepoint int
 }
 
 // GetTimeout returns timeout enforced in Tx.
@@ -19,6 +31,22 @@ func (tx *Tx) GetTimeout() time.Duration {
 	return tx.Timeout
 }
 
+// GetDryRun returns whether Tx is in dry-run mode.
+func (tx *Tx) GetDryRun() bool {
+	return tx.dryRun
+}
+
+// GetResultFallback returns the value ExecResult returns in Tx
+// when the driver cannot report a result.
+func (tx *Tx) GetResultFallback() int64 {
+	return tx.resultFallback
+}
+
+// GetRedactArgs returns whether the args of statements are redacted in Tx.
+func (tx *Tx) GetRedactArgs() bool {
+	return tx.redactArgs
+}
+
 // BeginTx creates a transaction with TxOptions.
 func (sess *Session) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
 	tx, err := sess.Connection.BeginTx(ctx, opts)
@@ -28,10 +56,18 @@ func (sess *Session) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, err
 	sess.Event("dbr.begin")
 
 	return &Tx{
-		EventReceiver: sess.EventReceiver,
-		Dialect:       sess.Dialect,
-		Tx:            tx,
-		Timeout:       sess.GetTimeout(),
+		EventReceiver:   sess.EventReceiver,
+		Dialect:         sess.Dialect,
+		Tx:              tx,
+		Timeout:         sess.GetTimeout(),
+		partition:       sess.partition,
+		insertBatchSize: sess.insertBatchSize,
+		dryRun:          sess.dryRun,
+		tableMapper:     sess.tableMapper,
+		resultFallback:  sess.resultFallback,
+		quietChunks:     sess.quietChunks,
+		redactArgs:      sess.redactArgs,
+		stmts:           sess.preparedCache(),
 	}, nil
 }
 
@@ -40,6 +76,75 @@ func (sess *Session) Begin() (*Tx, error) {
 	return sess.BeginTx(context.Background(), nil)
 }
 
+// Transaction runs fn in a transaction started with ctx.
+// The transaction is committed if fn returns nil, and rolled back
+// if fn returns an error or panics. A panic is re-raised after rollback.
+func (sess *Session) Transaction(ctx context.Context, fn func(tx *Tx) error) error {
+	tx, err := sess.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// ExecBatch executes stmts one by one in a transaction, e.g. the steps
+// of a migration. It stops at the first error and rolls back.
+// Each statement sends its own events, and an InsertStmt or
+// CaseUpdateStmt executes all its chunks.
+//
+// The statements are sent one round-trip each, not as one multi-statement
+// query, which drivers only allow with options like MySQL's
+// multiStatements and which would report one result and error for all.
+//
+// Note that MySQL commits implicitly after DDL like CREATE TABLE,
+// so those cannot be rolled back.
+func (sess *Session) ExecBatch(ctx context.Context, stmts ...Builder) error {
+	return sess.Transaction(ctx, func(tx *Tx) error {
+		for _, stmt := range stmts {
+			if err := tx.execStmt(ctx, stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// execStmt executes stmt in tx, whichever runner it was created with.
+// Chunked statements are executed with their own ExecContext.
+func (tx *Tx) execStmt(ctx context.Context, stmt Builder) error {
+	switch b := stmt.(type) {
+	case *InsertStmt:
+		if b.raw.Query != "" {
+			break
+		}
+		s := b.snapshot()
+		s.runner = tx
+		s.EventReceiver = tx.EventReceiver
+		s.Dialect = tx.Dialect
+		_, err := s.ExecContext(ctx)
+		return err
+	case *CaseUpdateStmt:
+		s := b.snapshot()
+		s.runner = tx
+		s.EventReceiver = tx.EventReceiver
+		s.Dialect = tx.Dialect
+		_, err := s.ExecContext(ctx)
+		return err
+	}
+	_, err := exec(ctx, tx, tx.EventReceiver, stmt, tx.Dialect)
+	return err
+}
+
 // Commit finishes the transaction.
 func (tx *Tx) Commit() error {
 	err := tx.Tx.Commit()
@@ -77,3 +182,56 @@ func (tx *Tx) RollbackUnlessCommitted() {
 		tx.Event("dbr.rollback")
 	}
 }
+
+// Savepoint creates a savepoint with name in the transaction.
+func (tx *Tx) Savepoint(name string) error {
+	return tx.execSavepoint(context.Background(), "dbr.savepoint", "SAVEPOINT "+tx.QuoteIdent(name))
+}
+
+// RollbackToSavepoint rolls back the transaction to the savepoint with name,
+// and keeps the transaction open.
+func (tx *Tx) RollbackToSavepoint(name string) error {
+	return tx.execSavepoint(context.Background(), "dbr.rollback_to_savepoint", "ROLLBACK TO SAVEPOINT "+tx.QuoteIdent(name))
+}
+
+// ReleaseSavepoint removes the savepoint with name, and keeps
+// the changes made after it.
+func (tx *Tx) ReleaseSavepoint(name string) error {
+	return tx.execSavepoint(context.Background(), "dbr.release_savepoint", "RELEASE SAVEPOINT "+tx.QuoteIdent(name))
+}
+
+// execSavepoint executes query like any other statement of tx,
+// so DryRun, the timeout, and QueryError apply to it.
+func (tx *Tx) execSavepoint(ctx context.Context, eventName, query string) error {
+	if _, err := exec(ctx, tx, tx.EventReceiver, Expr(query), tx.Dialect); err != nil {
+		return err
+	}
+	tx.EventKv(eventName, kvs{
+		"sql": query,
+	})
+	return nil
+}
+
+// Transaction runs fn as a nested transaction within tx using a savepoint.
+// The changes made by fn are released if it returns nil, and rolled back
+// to the savepoint if it returns an error or panics, leaving tx open.
+// A panic is re-raised after rollback.
+func (tx *Tx) Transaction(ctx context.Context, fn func(tx *Tx) error) error {
+	tx.savepoint++
+	name := tx.QuoteIdent("dbr_savepoint_" + strconv.Itoa(tx.savepoint))
+	err := tx.execSavepoint(ctx, "dbr.savepoint", "SAVEPOINT "+name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.execSavepoint(ctx, "dbr.rollback_to_savepoint", "ROLLBACK TO SAVEPOINT "+name)
+			panic(p)
+		}
+	}()
+	if err := fn(tx); err != nil {
+		tx.execSavepoint(ctx, "dbr.rollback_to_savepoint", "ROLLBACK TO SAVEPOINT "+name)
+		return err
+	}
+	return tx.execSavepoint(ctx, "dbr.release_savepoint", "RELEASE SAVEPOINT "+name)
+}
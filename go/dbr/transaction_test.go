@@ -0,0 +1,189 @@
+package dbr
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gavin2014/lib/go/dbr/dialect"
+)
+
+func TestTransaction(t *testing.T) {
+	update := func(tx *Tx) error {
+		_, err := tx.Update("t").Set("a", 1).Where("id = ?", 1).Exec()
+		return err
+	}
+
+	sess, db := newTestSession(dialect.MySQL)
+	if err := sess.Transaction(context.Background(), update); err != nil {
+		t.Fatal(err)
+	}
+	checkQueries(t, db, "BEGIN", "UPDATE `t` SET `a` = 1 WHERE (id = 1)", "COMMIT")
+
+	sess, db = newTestSession(dialect.MySQL)
+	errFail := errors.New("fail")
+	err := sess.Transaction(context.Background(), func(tx *Tx) error {
+		if err := update(tx); err != nil {
+			return err
+		}
+		return errFail
+	})
+	if err != errFail {
+		t.Fatalf("Transaction() = %v, want %v", err, errFail)
+	}
+	checkQueries(t, db, "BEGIN", "UPDATE `t` SET `a` = 1 WHERE (id = 1)", "ROLLBACK")
+
+	sess, db = newTestSession(dialect.MySQL)
+	func() {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Fatalf("recovered %v, want boom", p)
+			}
+		}()
+		sess.Transaction(context.Background(), func(tx *Tx) error {
+			update(tx)
+			panic("boom")
+		})
+	}()
+	checkQueries(t, db, "BEGIN", "UPDATE `t` SET `a` = 1 WHERE (id = 1)", "ROLLBACK")
+}
+
+func TestSavepoint(t *testing.T) {
+	sess, db := newTestSession(dialect.PostgreSQL)
+	tx, err := sess.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fn := range []func(string) error{tx.Savepoint, tx.RollbackToSavepoint, tx.ReleaseSavepoint} {
+		if err := fn("sp"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	errFail := errors.New("fail")
+	db.failOn(`RELEASE SAVEPOINT "missing"`, errFail)
+	err = tx.ReleaseSavepoint("missing")
+	var qe *QueryError
+	if !errors.As(err, &qe) || !errors.Is(err, errFail) || qe.SQL() != `RELEASE SAVEPOINT "missing"` {
+		t.Fatalf("ReleaseSavepoint() = %v, want a QueryError wrapping %v", err, errFail)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	checkQueries(t, db,
+		"BEGIN",
+		`SAVEPOINT "sp"`,
+		`ROLLBACK TO SAVEPOINT "sp"`,
+		`RELEASE SAVEPOINT "sp"`,
+		`RELEASE SAVEPOINT "missing"`,
+		"COMMIT",
+	)
+
+	sess, db = newTestSession(dialect.PostgreSQL)
+	sess.DryRun(true)
+	tx, err = sess.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Savepoint("sp"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	checkQueries(t, db, "BEGIN", "COMMIT")
+}
+
+func TestTxTransaction(t *testing.T) {
+	sess, db := newTestSession(dialect.MySQL)
+	tx, err := sess.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	update := func(tx *Tx) error {
+		_, err := tx.Update("t").Set("a", 1).Where("id = ?", 1).Exec()
+		return err
+	}
+	if err := tx.Transaction(context.Background(), update); err != nil {
+		t.Fatal(err)
+	}
+
+	errFail := errors.New("fail")
+	err = tx.Transaction(context.Background(), func(tx *Tx) error {
+		if err := update(tx); err != nil {
+			return err
+		}
+		return errFail
+	})
+	if err != errFail {
+		t.Fatalf("Transaction() = %v, want %v", err, errFail)
+	}
+
+	func() {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Fatalf("recovered %v, want boom", p)
+			}
+		}()
+		tx.Transaction(context.Background(), func(tx *Tx) error {
+			update(tx)
+			panic("boom")
+		})
+	}()
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	checkQueries(t, db,
+		"BEGIN",
+		"SAVEPOINT `dbr_savepoint_1`",
+		"UPDATE `t` SET `a` = 1 WHERE (id = 1)",
+		"RELEASE SAVEPOINT `dbr_savepoint_1`",
+		"SAVEPOINT `dbr_savepoint_2`",
+		"UPDATE `t` SET `a` = 1 WHERE (id = 1)",
+		"ROLLBACK TO SAVEPOINT `dbr_savepoint_2`",
+		"SAVEPOINT `dbr_savepoint_3`",
+		"UPDATE `t` SET `a` = 1 WHERE (id = 1)",
+		"ROLLBACK TO SAVEPOINT `dbr_savepoint_3`",
+		"COMMIT",
+	)
+}
+
+func TestExecBatch(t *testing.T) {
+	sess, db := newTestSession(dialect.MySQL)
+	err := sess.ExecBatch(context.Background(),
+		InsertInto("t").Columns("a").Values(1).Values(2).Values(3).SetRunLen(2),
+		CaseUpdate("t").Key("id").Set(1, "a", 10).Set(2, "a", 20).Set(3, "a", 30).SetRunLen(2),
+		DeleteFrom("t").Where("a = ?", 0),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkQueries(t, db,
+		"BEGIN",
+		"INSERT INTO `t` (`a`) VALUES (1), (2)",
+		"INSERT INTO `t` (`a`) VALUES (3)",
+		"UPDATE `t` SET `a` = CASE `id` WHEN '1' THEN 10  WHEN '2' THEN 20  END  WHERE `id` IN ( '1' ,  '2'  )",
+		"UPDATE `t` SET `a` = CASE `id` WHEN '3' THEN 30  END  WHERE `id` IN ( '3'  )",
+		"DELETE FROM `t` WHERE (a = 0)",
+		"COMMIT",
+	)
+}
+
+func TestExecBatchRollback(t *testing.T) {
+	sess, db := newTestSession(dialect.MySQL)
+	errFail := errors.New("fail")
+	db.failOn("DELETE FROM `t` WHERE (a = 0)", errFail)
+	err := sess.ExecBatch(context.Background(),
+		InsertInto("t").Columns("a").Values(1),
+		DeleteFrom("t").Where("a = ?", 0),
+		Update("t").Set("a", 2).Where("a = ?", 1),
+	)
+	if !errors.Is(err, errFail) {
+		t.Fatalf("ExecBatch() = %v, want %v", err, errFail)
+	}
+	checkQueries(t, db,
+		"BEGIN",
+		"INSERT INTO `t` (`a`) VALUES (1)",
+		"DELETE FROM `t` WHERE (a = 0)",
+		"ROLLBACK",
+	)
+}
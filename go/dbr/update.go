@@ -3,7 +3,11 @@ package dbr
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"sort"
 	"strconv"
+
+	"github.com/gavin2014/lib/go/dbr/dialect"
 )
 
 // UpdateStmt builds `UPDATE ...`.
@@ -14,14 +18,22 @@ type UpdateStmt struct {
 
 	raw
 
-	Table      string
-	Value      map[string]interface{}
-	WhereCond  []Builder
-	LimitCount int64
+	Table        string
+	Value        map[string]interface{}
+	WhereCond    []Builder
+	LimitCount   int64
+	ReturnColumn []string
+	QueryLabel   string
+	TableMapper  func(string) string
+
+	with      withClause
+	scanLimit int
 }
 
 type UpdateBuilder = UpdateStmt
 
+// Build writes the statement to buf.
+// An UPDATE without a WHERE condition returns ErrWhereNotSpecified.
 func (b *UpdateStmt) Build(d Dialect, buf Buffer) error {
 	if b.raw.Query != "" {
 		return b.raw.Build(d, buf)
@@ -35,12 +47,23 @@ func (b *UpdateStmt) Build(d Dialect, buf Buffer) error {
 		return ErrColumnNotSpecified
 	}
 
+	if err := b.with.Build(d, buf); err != nil {
+		return err
+	}
+
 	buf.WriteString("UPDATE ")
-	buf.WriteString(d.QuoteIdent(b.Table))
+	buf.WriteString(d.QuoteIdent(mapTable(b.TableMapper, b.Table)))
 	buf.WriteString(" SET ")
 
-	i := 0
-	for col, v := range b.Value {
+	// sort the columns, so the same columns always give the same SQL,
+	// e.g. for CacheStatements.
+	column := make([]string, 0, len(b.Value))
+	for col := range b.Value {
+		column = append(column, col)
+	}
+	sort.Strings(column)
+	for i, col := range column {
+		v := b.Value[col]
 		if i > 0 {
 			buf.WriteString(", ")
 		}
@@ -53,27 +76,50 @@ func (b *UpdateStmt) Build(d Dialect, buf Buffer) error {
 			buf.WriteString(placeholder)
 			buf.WriteValue(v)
 		}
-		i++
 	}
 
-	if len(b.WhereCond) > 0 {
-		buf.WriteString(" WHERE ")
-		err := And(b.WhereCond...).Build(d, buf)
-		if err != nil {
-			return err
-		}
-	}else{
-		panic("没有条件")
+	if len(b.WhereCond) == 0 {
+		return ErrWhereNotSpecified
+	}
+	buf.WriteString(" WHERE ")
+	err := And(b.WhereCond...).Build(d, buf)
+	if err != nil {
+		return err
 	}
 
-	if b.LimitCount >= 0 {
-		buf.WriteString(" LIMIT ")
-		buf.WriteString(strconv.FormatInt(b.LimitCount, 10))
+	if err := buildModifyLimit(d, buf, b.LimitCount); err != nil {
+		return err
+	}
+
+	if len(b.ReturnColumn) > 0 {
+		buf.WriteString(" RETURNING ")
+		for i, col := range b.ReturnColumn {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			buf.WriteString(d.QuoteIdent(col))
+		}
 	}
 
 	return nil
 }
 
+// buildModifyLimit writes the LIMIT of UPDATE or DELETE if n >= 0.
+// Only MySQL supports it, so ErrNotSupported is returned for other dialects.
+// SQLite is rejected too on purpose: it only parses the LIMIT when built with
+// SQLITE_ENABLE_UPDATE_DELETE_LIMIT, which common builds and drivers are not.
+func buildModifyLimit(d Dialect, buf Buffer, n int64) error {
+	if n < 0 {
+		return nil
+	}
+	if !isDialect(d, dialect.MySQL) {
+		return fmt.Errorf("%w: LIMIT in UPDATE or DELETE, use a subquery instead", ErrNotSupported)
+	}
+	buf.WriteString(" LIMIT ")
+	buf.WriteString(strconv.FormatInt(n, 10))
+	return nil
+}
+
 // Update creates an UpdateStmt.
 func Update(table string) *UpdateStmt {
 	return &UpdateStmt{
@@ -89,6 +135,7 @@ func (sess *Session) Update(table string) *UpdateStmt {
 	b.runner = sess
 	b.EventReceiver = sess.EventReceiver
 	b.Dialect = sess.Dialect
+	b.TableMapper = sess.tableMapper
 	return b
 }
 
@@ -98,6 +145,7 @@ func (tx *Tx) Update(table string) *UpdateStmt {
 	b.runner = tx
 	b.EventReceiver = tx.EventReceiver
 	b.Dialect = tx.Dialect
+	b.TableMapper = tx.tableMapper
 	return b
 }
 
@@ -131,8 +179,26 @@ func (tx *Tx) UpdateBySql(query string, value ...interface{}) *UpdateStmt {
 	return b
 }
 
+// With adds a common table expression, e.g. a SELECT or a data-modifying
+// statement with RETURNING, to be referred to by name in the statement:
+// `WITH name AS (...) UPDATE ...`.
+func (b *UpdateStmt) With(name string, builder Builder) *UpdateStmt {
+	b.with.add(name, builder, false)
+	return b
+}
+
+// WithRecursive is like With, but writes `WITH RECURSIVE`.
+func (b *UpdateStmt) WithRecursive(name string, builder Builder) *UpdateStmt {
+	b.with.add(name, builder, true)
+	return b
+}
+
 // Where adds a where condition.
 // query can be Builder or string. value is used only if query type is string.
+// Conditions added by Where are joined with AND. Use And and Or to nest them,
+// e.g. `(a = ? OR b = ?) AND c = ?`:
+//
+//	Where(dbr.Or(dbr.Eq("a", 1), dbr.Eq("b", 2))).Where(dbr.Eq("c", 3))
 func (b *UpdateStmt) Where(query interface{}, value ...interface{}) *UpdateStmt {
 	switch query := query.(type) {
 	case string:
@@ -143,6 +209,12 @@ func (b *UpdateStmt) Where(query interface{}, value ...interface{}) *UpdateStmt
 	return b
 }
 
+// WhereInStructs adds `column IN (...)` with the values of field
+// of each struct in slice. See InStructs.
+func (b *UpdateStmt) WhereInStructs(column string, slice interface{}, field string) *UpdateStmt {
+	return b.Where(InStructs(column, slice, field))
+}
+
 // Set updates column with value.
 func (b *UpdateStmt) Set(column string, value interface{}) *UpdateStmt {
 	b.Value[column] = value
@@ -157,16 +229,64 @@ func (b *UpdateStmt) SetMap(m map[string]interface{}) *UpdateStmt {
 	return b
 }
 
+// Limit updates at most n rows with `LIMIT n`, which only MySQL supports.
+// Build returns ErrNotSupported for other dialects, SQLite included, e.g. on postgres
+// limit the rows with a subquery like `WHERE id IN (SELECT id ... LIMIT n)`.
 func (b *UpdateStmt) Limit(n uint64) *UpdateStmt {
 	b.LimitCount = int64(n)
 	return b
 }
 
+// Returning specifies the returning columns for postgres.
+func (b *UpdateStmt) Returning(column ...string) *UpdateStmt {
+	b.ReturnColumn = column
+	return b
+}
+
+// ScanLimit sets Load to scan at most n of the rows returned by RETURNING,
+// e.g. to log a sample of a large update. The rest are discarded,
+// and every matched row is still updated. 0 scans all rows.
+func (b *UpdateStmt) ScanLimit(n int) *UpdateStmt {
+	b.scanLimit = n
+	return b
+}
+
+// Reset clears the values, conditions, and options of the statement
+// so it can be reused, e.g. from a sync.Pool, keeping its table,
+// runner, Dialect, and EventReceiver. The session itself is not reset.
+func (b *UpdateStmt) Reset() *UpdateStmt {
+	b.raw = raw{}
+	// the map and slice may be the caller's, so they are not reused
+	b.Value = make(map[string]interface{})
+	b.WhereCond = nil
+	b.LimitCount = -1
+	b.ReturnColumn = nil
+	b.QueryLabel = ""
+	b.with = withClause{}
+	b.scanLimit = 0
+	return b
+}
+
+// Label names the statement. See SelectStmt.Label.
+func (b *UpdateStmt) Label(name string) *UpdateStmt {
+	b.QueryLabel = name
+	return b
+}
+
 //获取SQL
-func (b *UpdateStmt) GetSQL() (string, error) {
+// An optional dialect like NoQuote(nil) overrides the dialect of the statement.
+// A statement created without a session is written with MySQL by default.
+func (b *UpdateStmt) GetSQL(d ...Dialect) (string, error) {
 	b1 := *b
 	b2 := &b1
-	return getSQL(b2, b2.Dialect)
+	return getSQL(b2, previewDialect(b2.Dialect, d))
+}
+
+// Prepare builds the statement without executing it, and returns
+// the SQL with placeholders of its dialect and the values bound to them,
+// e.g. to validate it or run it with another *sql.DB.
+func (b *UpdateStmt) Prepare() (string, []interface{}, error) {
+	return prepareQuery(b, previewDialect(b.Dialect, nil))
 }
 
 func (b *UpdateStmt) Exec() (sql.Result, error) {
@@ -176,3 +296,45 @@ func (b *UpdateStmt) Exec() (sql.Result, error) {
 func (b *UpdateStmt) ExecContext(ctx context.Context) (sql.Result, error) {
 	return exec(ctx, b.runner, b.EventReceiver, b, b.Dialect)
 }
+
+// ExecR executes the statement like ExecContext,
+// and wraps its result in an ExecResult.
+func (b *UpdateStmt) ExecR(ctx context.Context) (*ExecResult, error) {
+	result, err := b.ExecContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newExecResult(b.runner, result), nil
+}
+
+// LoadContext executes the statement and loads the rows returned by RETURNING
+// into value, up to the limit set by ScanLimit.
+func (b *UpdateStmt) LoadContext(ctx context.Context, value interface{}) error {
+	if b.scanLimit > 0 {
+		value = limitLoader{value, b.scanLimit}
+	}
+	_, err := query(ctx, b.runner, b.EventReceiver, b, b.Dialect, value)
+	return err
+}
+
+func (b *UpdateStmt) Load(value interface{}) error {
+	return b.LoadContext(context.Background(), value)
+}
+
+// LoadOneContext executes the statement and loads the single row returned
+// by RETURNING into value, which is not a slice.
+// It returns ErrNotFound if no row is returned, and ErrMultipleRows
+// if more than one is, in which case value holds the first row.
+// ScanLimit does not apply.
+func (b *UpdateStmt) LoadOneContext(ctx context.Context, value interface{}) error {
+	count, err := query(ctx, b.runner, b.EventReceiver, b, b.Dialect, oneLoader{value})
+	if err != nil {
+		return err
+	}
+	return checkOne(count)
+}
+
+// LoadOne is like LoadOneContext with the background context.
+func (b *UpdateStmt) LoadOne(value interface{}) error {
+	return b.LoadOneContext(context.Background(), value)
+}
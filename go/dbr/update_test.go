@@ -0,0 +1,110 @@
+package dbr
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/gavin2014/lib/go/dbr/dialect"
+)
+
+func TestUpdateScanLimit(t *testing.T) {
+	sess, db := newTestSession(dialect.PostgreSQL)
+	var value [][]driver.Value
+	for i := 0; i < 1000; i++ {
+		value = append(value, []driver.Value{int64(i)})
+	}
+	db.addRows([]string{"id"}, value...)
+	var id []int64
+	err := sess.Update("orders").Set("state", "done").Where("state = ?", "new").
+		Returning("id").
+		ScanLimit(3).
+		Load(&id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkQueries(t, db, `UPDATE "orders" SET "state" = 'done' WHERE (state = 'new') RETURNING "id"`)
+	if len(id) != 3 || id[0] != 0 || id[2] != 2 {
+		t.Fatalf("loaded %v, want the first 3 ids", id)
+	}
+}
+
+func TestUpdateResetPool(t *testing.T) {
+	sess, db := newTestSession(dialect.MySQL)
+	pool := sync.Pool{New: func() interface{} { return sess.Update("t") }}
+
+	value := map[string]interface{}{"a": 1}
+	where := append(make([]Builder, 0, 4), Eq("id", 1))
+	stmt := pool.Get().(*UpdateStmt)
+	stmt.SetMap(value)
+	stmt.WhereCond = where
+	if _, err := stmt.Exec(); err != nil {
+		t.Fatal(err)
+	}
+	pool.Put(stmt.Reset())
+
+	stmt = pool.Get().(*UpdateStmt)
+	if _, err := stmt.Set("b", 2).Where(Eq("id", 2)).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if len(value) != 1 {
+		t.Fatalf("Reset changed the map passed to SetMap: %v", value)
+	}
+	checkBuild(t, where[0], dialect.MySQL, "`id` = ?", 1)
+	checkQueries(t, db,
+		"UPDATE `t` SET `a` = 1 WHERE (`id` = 1)",
+		"UPDATE `t` SET `b` = 2 WHERE (`id` = 2)",
+	)
+}
+
+func TestUpdateLoadOne(t *testing.T) {
+	sess, db := newTestSession(dialect.PostgreSQL)
+	db.addRows([]string{"id"}, []driver.Value{int64(1)})
+	db.addRows([]string{"id"})
+	db.addRows([]string{"id"}, []driver.Value{int64(2)}, []driver.Value{int64(3)})
+	for _, want := range []struct {
+		id  int64
+		err error
+	}{
+		{1, nil},
+		{0, ErrNotFound},
+		{2, ErrMultipleRows},
+	} {
+		var id int64
+		err := sess.Update("t").Set("a", 1).Where("b = ?", 2).Returning("id").LoadOne(&id)
+		if err != want.err || id != want.id {
+			t.Errorf("LoadOne() = %d, %v, want %d, %v", id, err, want.id, want.err)
+		}
+	}
+}
+
+func TestUpdatePrepare(t *testing.T) {
+	stmt := Update("t").Set("c", 3).Set("a", 1).Set("b", 2).Where("id = ?", 4)
+	stmt.Dialect = dialect.PostgreSQL
+	for i := 0; i < 10; i++ {
+		query, value, err := stmt.Prepare()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := `UPDATE "t" SET "a" = $1, "b" = $2, "c" = $3 WHERE (id = $4)`; query != want {
+			t.Fatalf("Prepare() = %s, want %s", query, want)
+		}
+		if fmt.Sprint(value) != "[1 2 3 4]" {
+			t.Fatalf("Prepare() values = %v", value)
+		}
+	}
+}
+
+func TestUpdateWhereNotSpecified(t *testing.T) {
+	sess, db := newTestSession(dialect.MySQL)
+	stmt := sess.Update("t").Set("a", 1)
+	if err := stmt.Build(dialect.MySQL, NewBuffer()); !errors.Is(err, ErrWhereNotSpecified) {
+		t.Fatalf("Build() = %v, want ErrWhereNotSpecified", err)
+	}
+	if _, err := stmt.Exec(); !errors.Is(err, ErrWhereNotSpecified) {
+		t.Fatalf("Exec() = %v, want ErrWhereNotSpecified", err)
+	}
+	checkQueries(t, db)
+}
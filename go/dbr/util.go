@@ -49,21 +49,30 @@ var (
 )
 
 type tagStore struct {
-	m map[reflect.Type][]string
+	m map[reflect.Type][]fieldTag
+}
+
+// fieldTag is the column name of a struct field and its options
+// like `db:"name,omitempty"`, and the layout from its `dblayout` tag.
+type fieldTag struct {
+	name      string
+	layout    string
+	omitEmpty bool
+	generated bool
 }
 
 func newTagStore() *tagStore {
 	return &tagStore{
-		m: make(map[reflect.Type][]string),
+		m: make(map[reflect.Type][]fieldTag),
 	}
 }
 
-func (s *tagStore) get(t reflect.Type) []string {
+func (s *tagStore) get(t reflect.Type) []fieldTag {
 	if t.Kind() != reflect.Struct {
 		return nil
 	}
 	if _, ok := s.m[t]; !ok {
-		l := make([]string, t.NumField())
+		l := make([]fieldTag, t.NumField())
 		for i := 0; i < t.NumField(); i++ {
 			field := t.Field(i)
 			if field.PkgPath != "" && !field.Anonymous {
@@ -75,17 +84,74 @@ func (s *tagStore) get(t reflect.Type) []string {
 				// ignore
 				continue
 			}
+			option := strings.Split(tag, ",")
+			tag = option[0]
 			if tag == "" {
 				// no tag, but we can record the field name
 				tag = NameMapping(field.Name)
 			}
-			l[i] = tag
+			l[i].name = tag
+			for _, opt := range option[1:] {
+				switch opt {
+				case "omitempty":
+					l[i].omitEmpty = true
+				case "generated":
+					l[i].generated = true
+				}
+			}
+			if field.Type == typeTime {
+				l[i].layout = field.Tag.Get("dblayout")
+			}
 		}
 		s.m[t] = l
 	}
 	return s.m[t]
 }
 
+// ColumnsOf returns the columns of a struct or a pointer to it in field order,
+// e.g. for `InsertInto(table).Columns(dbr.ColumnsOf(user)...)`.
+// The columns are found the same way as Record: db tags are used,
+// and unexported fields or those tagged `db:"-"` are skipped.
+// The fields of embedded structs are included in place.
+func ColumnsOf(structValue interface{}) []string {
+	t := reflect.TypeOf(structValue)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	var column []string
+	newTagStore().columns(t, make(map[string]bool), &column)
+	return column
+}
+
+func (s *tagStore) columns(t reflect.Type, seen map[string]bool, column *[]string) {
+	l := s.get(t)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous {
+			if field.PkgPath != "" {
+				continue
+			}
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				s.columns(ft, seen, column)
+			}
+			continue
+		}
+		name := l[i].name
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		*column = append(*column, name)
+	}
+}
+
 func (s *tagStore) findPtr(value reflect.Value, name []string, ptr []interface{}) error {
 	if value.CanAddr() && value.Addr().Type().Implements(typeScanner) {
 		ptr[0] = value.Addr().Interface()
@@ -122,6 +188,30 @@ func (s *tagStore) findPtr(value reflect.Value, name []string, ptr []interface{}
 	}
 }
 
+// emptyField is a zero field tagged omitempty, found by findValueByName.
+type emptyField struct {
+	reflect.Value
+}
+
+// generatedField is a field tagged generated, found by findValueByName.
+type generatedField struct {
+	reflect.Value
+}
+
+// fieldValue returns the field of v found by findValueByName,
+// whether or not it is an emptyField or a generatedField.
+func fieldValue(v interface{}) (reflect.Value, bool) {
+	switch v := v.(type) {
+	case generatedField:
+		return v.Value, true
+	case emptyField:
+		return v.Value, true
+	case reflect.Value:
+		return v, true
+	}
+	return reflect.Value{}, false
+}
+
 func (s *tagStore) findValueByName(value reflect.Value, name []string, ret []interface{}, retPtr bool) {
 	if value.Type().Implements(typeValuer) {
 		return
@@ -135,7 +225,7 @@ func (s *tagStore) findValueByName(value reflect.Value, name []string, ret []int
 	case reflect.Struct:
 		l := s.get(value.Type())
 		for i := 0; i < value.NumField(); i++ {
-			tag := l[i]
+			tag, layout, omitEmpty, generated := l[i].name, l[i].layout, l[i].omitEmpty, l[i].generated
 			if tag == "" {
 				continue
 			}
@@ -145,7 +235,13 @@ func (s *tagStore) findValueByName(value reflect.Value, name []string, ret []int
 					continue
 				}
 				if ret[i] == nil {
-					if retPtr {
+					if !retPtr && generated {
+						ret[i] = generatedField{fieldValue}
+					} else if !retPtr && omitEmpty && fieldValue.IsZero() {
+						ret[i] = emptyField{fieldValue}
+					} else if layout != "" {
+						ret[i] = layoutValue(fieldValue, layout, retPtr)
+					} else if retPtr {
 						ret[i] = fieldValue.Addr().Interface()
 					} else {
 						ret[i] = fieldValue
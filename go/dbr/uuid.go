@@ -0,0 +1,28 @@
+package dbr
+
+import "github.com/gavin2014/lib/go/dbr/dialect"
+
+// sqliteUUID generates a version 4 UUID, since SQLite has no function for it.
+const sqliteUUID = "lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || " +
+	"substr(hex(randomblob(2)), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || " +
+	"substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6)))"
+
+// UUID returns an expression that generates a UUID in the database,
+// e.g. for Values. It is `UUID()` on MySQL, `gen_random_uuid()` on PostgreSQL
+// (13 or later, or with pgcrypto), and an equivalent expression on SQLite.
+// Build returns ErrNotSupported for other dialects.
+func UUID() Builder {
+	return BuildFunc(func(d Dialect, buf Buffer) error {
+		switch {
+		case isDialect(d, dialect.MySQL):
+			buf.WriteString("UUID()")
+		case isDialect(d, dialect.PostgreSQL):
+			buf.WriteString("gen_random_uuid()")
+		case isDialect(d, dialect.SQLite3):
+			buf.WriteString(sqliteUUID)
+		default:
+			return ErrNotSupported
+		}
+		return nil
+	})
+}
@@ -0,0 +1,16 @@
+package dbr
+
+import (
+	"testing"
+
+	"github.com/gavin2014/lib/go/dbr/dialect"
+)
+
+func TestUUID(t *testing.T) {
+	checkBuild(t, UUID(), dialect.MySQL, "UUID()")
+	checkBuild(t, UUID(), dialect.PostgreSQL, "gen_random_uuid()")
+	checkBuild(t, UUID(), dialect.SQLite3, sqliteUUID)
+
+	stmt := InsertInto("t").Columns("id", "name").Values(UUID(), "a")
+	checkBuild(t, stmt, dialect.PostgreSQL, `INSERT INTO "t" ("id","name") VALUES (gen_random_uuid(),?)`, "a")
+}